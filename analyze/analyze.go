@@ -0,0 +1,243 @@
+// Package analyze characterises unknown ciphertexts using byte statistics and
+// block structure, and guesses which kind of cipher produced them.
+package analyze
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Kind is a family of ciphertexts Analyze can tell apart.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	XOR
+	ECB
+	CBC
+	Stream
+)
+
+func (k Kind) String() string {
+	switch k {
+	case XOR:
+		return "xor"
+	case ECB:
+		return "ecb"
+	case CBC:
+		return "cbc"
+	case Stream:
+		return "stream"
+	default:
+		return "unknown"
+	}
+}
+
+// BlockSizes are the block sizes checked for alignment and repetition.
+var BlockSizes = []int{16, 8, 32}
+
+const (
+	maxKeySize = 40
+	// minColumn is the shortest transposed column worth computing an index of
+	// coincidence over.
+	minColumn = 8
+	// xorThreshold is the normalized index of coincidence above which a
+	// column is considered to be structured plaintext under a single key byte.
+	// Uniformly random bytes sit at 1.0, English text at around 15.
+	xorThreshold = 3.0
+	// uniformZ is the chi-square z-score below which the byte histogram is
+	// accepted as uniform, roughly p > 0.01.
+	uniformZ = 2.33
+)
+
+// Report holds the statistics Analyze computes for a ciphertext, along with
+// its best guess at the cipher and the reasons for it.
+type Report struct {
+	Length  int
+	Entropy float64
+	// ChiSquare is measured against a uniform byte distribution with 255
+	// degrees of freedom, and ChiSquareZ is its normal approximation.
+	ChiSquare  float64
+	ChiSquareZ float64
+	// IndexOfCoincidence is normalized so that random data scores 1.
+	IndexOfCoincidence float64
+	LengthMod          map[int]int
+	RepeatedBlocks     map[int]int
+	// KeySize is the repeating XOR key length whose transposed columns have
+	// the highest index of coincidence, and KeySizeIC that index.
+	KeySize   int
+	KeySizeIC float64
+
+	Guess   Kind
+	Reasons []string
+}
+
+func histogram(b []byte) (counts [256]int) {
+	for _, c := range b {
+		counts[c]++
+	}
+	return counts
+}
+
+// Entropy returns the Shannon entropy of b in bits per byte.
+func Entropy(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	var h float64
+	for _, n := range histogram(b) {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(len(b))
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// ChiSquare returns the chi-square statistic of b's byte histogram against a
+// uniform distribution.
+func ChiSquare(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	expected := float64(len(b)) / 256
+	var x float64
+	for _, n := range histogram(b) {
+		d := float64(n) - expected
+		x += d * d / expected
+	}
+	return x
+}
+
+// chiSquareZ converts a chi-square statistic with df degrees of freedom to an
+// approximate standard normal score using the Wilson-Hilferty transform.
+func chiSquareZ(x float64, df int) float64 {
+	k := float64(df)
+	v := 2 / (9 * k)
+	return (math.Cbrt(x/k) - (1 - v)) / math.Sqrt(v)
+}
+
+// IndexOfCoincidence returns the probability that two bytes drawn from b
+// without replacement are equal, multiplied by 256 so that uniformly random
+// data scores 1.
+func IndexOfCoincidence(b []byte) float64 {
+	if len(b) < 2 {
+		return 0
+	}
+
+	var sum int
+	for _, n := range histogram(b) {
+		sum += n * (n - 1)
+	}
+	return 256 * float64(sum) / float64(len(b)*(len(b)-1))
+}
+
+// RepeatedBlocks counts blocks of b that are equal to an earlier block.
+func RepeatedBlocks(b []byte, blen int) int {
+	seen := make(map[string]bool)
+	var repeats int
+	for block := range slices.Chunk(b, blen) {
+		if len(block) < blen {
+			break
+		}
+		if seen[string(block)] {
+			repeats++
+		}
+		seen[string(block)] = true
+	}
+	return repeats
+}
+
+// KeySizeIC returns the mean index of coincidence of b transposed into size
+// columns, as when attacking repeating-key XOR.
+func KeySizeIC(b []byte, size int) float64 {
+	var total float64
+	for col := range size {
+		var column []byte
+		for i := col; i < len(b); i += size {
+			column = append(column, b[i])
+		}
+		total += IndexOfCoincidence(column)
+	}
+	return total / float64(size)
+}
+
+// Analyze computes statistics for ctxt and guesses what produced it.
+func Analyze(ctxt []byte) Report {
+	r := Report{
+		Length:             len(ctxt),
+		Entropy:            Entropy(ctxt),
+		ChiSquare:          ChiSquare(ctxt),
+		IndexOfCoincidence: IndexOfCoincidence(ctxt),
+		LengthMod:          make(map[int]int),
+		RepeatedBlocks:     make(map[int]int),
+	}
+	r.ChiSquareZ = chiSquareZ(r.ChiSquare, 255)
+
+	for _, blen := range BlockSizes {
+		r.LengthMod[blen] = len(ctxt) % blen
+		r.RepeatedBlocks[blen] = RepeatedBlocks(ctxt, blen)
+	}
+
+	for size := 1; size <= maxKeySize && len(ctxt)/size >= minColumn; size++ {
+		// Prefer the smallest size, since multiples of the real key size
+		// score just as well.
+		if ic := KeySizeIC(ctxt, size); ic > r.KeySizeIC*1.1 {
+			r.KeySize, r.KeySizeIC = size, ic
+		}
+	}
+
+	r.Guess, r.Reasons = guess(r)
+	return r
+}
+
+func guess(r Report) (Kind, []string) {
+	if r.Length == 0 {
+		return Unknown, []string{"empty input"}
+	}
+
+	var reasons []string
+	for _, blen := range BlockSizes {
+		if r.LengthMod[blen] == 0 && r.RepeatedBlocks[blen] > 0 {
+			reasons = append(reasons,
+				fmt.Sprintf("%d repeated %d-byte blocks", r.RepeatedBlocks[blen], blen),
+				fmt.Sprintf("length %d is a multiple of %d", r.Length, blen))
+			return ECB, reasons
+		}
+	}
+
+	if r.KeySizeIC > xorThreshold {
+		reasons = append(reasons, fmt.Sprintf("columns at key size %d have index of coincidence %.2f (random is 1.00)", r.KeySize, r.KeySizeIC))
+		if r.KeySize == 1 {
+			reasons = append(reasons, "single byte key, or not encrypted at all")
+		}
+		return XOR, reasons
+	}
+
+	uniform := r.ChiSquareZ < uniformZ
+	if uniform {
+		reasons = append(reasons, fmt.Sprintf("byte histogram is consistent with uniform (chi-square %.1f, z %.2f)", r.ChiSquare, r.ChiSquareZ))
+	} else {
+		reasons = append(reasons, fmt.Sprintf("byte histogram is not uniform (chi-square %.1f, z %.2f)", r.ChiSquare, r.ChiSquareZ))
+	}
+	reasons = append(reasons, fmt.Sprintf("entropy %.2f bits per byte", r.Entropy))
+
+	for _, blen := range BlockSizes {
+		if r.LengthMod[blen] == 0 {
+			reasons = append(reasons,
+				fmt.Sprintf("length %d is a multiple of %d", r.Length, blen),
+				"no repeated blocks")
+			return CBC, reasons
+		}
+	}
+
+	reasons = append(reasons, fmt.Sprintf("length %d is not a multiple of any common block size", r.Length))
+	if !uniform {
+		return Unknown, reasons
+	}
+	return Stream, reasons
+}
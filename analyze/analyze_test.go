@@ -0,0 +1,109 @@
+package analyze
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/random"
+)
+
+const text = `It was the best of times, it was the worst of times, it was the age of
+wisdom, it was the age of foolishness, it was the epoch of belief, it was the
+epoch of incredulity, it was the season of Light, it was the season of
+Darkness, it was the spring of hope, it was the winter of despair, we had
+everything before us, we had nothing before us, we were all going direct to
+Heaven, we were all going direct the other way - in short, the period was so
+far like the present period, that some of its noisiest authorities insisted on
+its being received, for good or for evil, in the superlative degree of
+comparison only.`
+
+func TestAnalyze(t *testing.T) {
+	rand := random.Seeded(1)
+	key := random.Bytes(rand, 16)
+
+	xor := []byte(text)
+	for i := range xor {
+		xor[i] ^= "ICE"[i%3]
+	}
+
+	ecb, err := modes.NewAESECB(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecbCtxt := make([]byte, 160)
+	ecb.HandleBytes(ecbCtxt, bytes.Repeat([]byte("YELLOW SUBMARINE"), 10), true)
+
+	cbc, err := modes.NewAESCBC(key, random.Bytes(rand, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cbcCtxt := make([]byte, 4096)
+	cbc.HandleBytes(cbcCtxt, make([]byte, 4096), true)
+
+	ctr, err := modes.NewAESCTR(key, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Random bytes that never have the top bit set are far from uniform,
+	// with no key structure, and not block aligned.
+	skewed := random.Bytes(rand, 3001)
+	for i := range skewed {
+		skewed[i] &= 0x7f
+	}
+
+	ctrCtxt := make([]byte, 4001)
+	ctr.XORKeyStream(ctrCtxt, bytes.Repeat([]byte("A"), 4001))
+
+	tests := []struct {
+		name string
+		ctxt []byte
+		want Kind
+	}{
+		{"empty", nil, Unknown},
+		{"repeating-key xor", xor, XOR},
+		{"plaintext", []byte(text), XOR},
+		{"ecb", ecbCtxt, ECB},
+		{"cbc", cbcCtxt, CBC},
+		{"ctr", ctrCtxt, Stream},
+		{"skewed", skewed, Unknown},
+	}
+	for _, tt := range tests {
+		r := Analyze(tt.ctxt)
+		if r.Guess != tt.want {
+			t.Errorf("%s: guessed %v, want %v, because %q", tt.name, r.Guess, tt.want, r.Reasons)
+		}
+		if len(r.Reasons) == 0 {
+			t.Errorf("%s: no reasons given", tt.name)
+		}
+	}
+}
+
+func TestAnalyzeKeySize(t *testing.T) {
+	ctxt := []byte(text)
+	for i := range ctxt {
+		ctxt[i] ^= "YELLOW"[i%6]
+	}
+	if r := Analyze(ctxt); r.KeySize != 6 {
+		t.Errorf("key size %d, want 6", r.KeySize)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	if e := Entropy(all); e != 8 {
+		t.Errorf("Entropy of every byte once = %v, want 8", e)
+	}
+	if x := ChiSquare(all); x != 0 {
+		t.Errorf("ChiSquare of every byte once = %v, want 0", x)
+	}
+	if e := Entropy(bytes.Repeat([]byte{'a'}, 100)); e != 0 {
+		t.Errorf("Entropy of one repeated byte = %v, want 0", e)
+	}
+	if n := RepeatedBlocks(bytes.Repeat([]byte("0123456789abcdef"), 3), 16); n != 2 {
+		t.Errorf("RepeatedBlocks = %d, want 2", n)
+	}
+}
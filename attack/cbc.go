@@ -0,0 +1,193 @@
+package attack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+// CBCBitflip gets admin=true into a userdata string without ever having it
+// encrypted. Decrypting with a zeroed IV reveals the raw block cipher output
+// for the first block, so setting the IV to that output XOR the wanted text
+// makes the first block decrypt to exactly that text.
+func CBCBitflip(ctx context.Context, o oracle.EncDecOracle, opts Options) ([]byte, error) {
+	r := opts.start()
+	m := newMeter()
+	encDec := r.encDec(ctx, o)
+	query := func(src []byte, enc bool) ([]byte, error) {
+		m.queries++
+		return encDec(src, enc)
+	}
+
+	ctxt, err := query([]byte("foobar"), true)
+	if err != nil {
+		return nil, r.finish(err)
+	}
+	if len(ctxt) < 32 {
+		return nil, r.finish(errors.New("ciphertext too short"))
+	}
+	r.emit(Started{Attack: "bitflip", Length: 16})
+
+	zeroBlock := make([]byte, 16)
+	copy(ctxt, zeroBlock)
+	ptxt, err := query(ctxt, false)
+	if err != nil {
+		return nil, r.finish(err)
+	}
+	if len(ptxt) < 16 {
+		return nil, r.finish(errors.New("oracle rejected the modified ciphertext"))
+	}
+	rawDecrypted := ptxt[:16]
+
+	inj := []byte("admin=true;;;;;;")
+	modes.XORBytes(rawDecrypted, inj)
+	copy(ctxt, rawDecrypted)
+
+	if ptxt, err = query(ctxt, false); err != nil {
+		return nil, r.finish(err)
+	}
+	if !oracle.IsAdmin(ptxt) {
+		return ptxt, r.finish(errors.New("admin=true did not survive decryption"))
+	}
+	for i, b := range inj {
+		r.emit(ByteRecovered{Offset: i, Value: b})
+	}
+	r.emit(m.completed(0, ptxt[:16]))
+	return ptxt, r.finish(nil)
+}
+
+// PaddingOracle decrypts ctxt, an IV followed by AES-CBC ciphertext, using
+// only an oracle that reports whether a ciphertext's padding is valid.
+// Blocks are independent, so up to opts.Concurrency of them are worked on at
+// once; the first block to fail cancels the rest. If interrupted it returns
+// the leading blocks recovered so far.
+func PaddingOracle(ctx context.Context, ctxt []byte, o oracle.PaddingOracle, opts Options) ([]byte, error) {
+	const blen = 16
+	r := opts.start()
+	if len(ctxt) < 2*blen || len(ctxt)%blen != 0 {
+		return nil, r.finish(fmt.Errorf("ciphertext length %d is not at least two whole blocks", len(ctxt)))
+	}
+	r.emit(Started{Attack: "padding", Length: len(ctxt) - blen})
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	check := r.validPadding(ctx, o)
+
+	blocks := slices.Collect(slices.Chunk(ctxt, blen))
+	decrypted := make([][]byte, len(blocks)-1)
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	next := make(chan int)
+	for range max(opts.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				m := newMeter()
+				dec, err := paddingOracleBlock(blocks[i], blocks[i+1], count(m, check), r, i*blen)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("block %d: %w", i+1, err)
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				decrypted[i] = dec
+				r.emit(m.completed(i, dec))
+			}
+		}()
+	}
+	for i := range decrypted {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var ptxt []byte
+	for _, dec := range decrypted {
+		if dec == nil {
+			break
+		}
+		ptxt = append(ptxt, dec...)
+	}
+	if firstErr != nil {
+		return ptxt, r.finish(firstErr)
+	}
+	if len(ptxt) < len(ctxt)-blen {
+		// Cancelled before every block was handed to a worker.
+		return ptxt, r.finish(&InterruptedError{Cause: ctx.Err(), Queries: r.queries})
+	}
+
+	ptxt, err := modes.PKCS7Strip(ptxt, blen)
+	return ptxt, r.finish(err)
+}
+
+// paddingOracleBlock recovers one plaintext block. It works from the last
+// byte backwards, choosing a fake previous block that makes the padding
+// valid; the block cipher output at that position is then the guess XOR the
+// padding byte.
+func paddingOracleBlock(prev, block []byte, valid func([]byte) (bool, error), r *run, offset int) ([]byte, error) {
+	blen := len(block)
+	unciphered := make([]byte, blen)
+	forged := make([]byte, blen)
+
+	for pos := blen - 1; pos >= 0; pos-- {
+		pad := byte(blen - pos)
+		for j := pos + 1; j < blen; j++ {
+			forged[j] = unciphered[j] ^ pad
+		}
+
+		found := false
+		for i := range 256 {
+			forged[pos] = byte(i)
+			ok, err := valid(slices.Concat(forged, block))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			// For the last byte, a hit might be longer padding such as
+			// 02 02 that happens to line up. Disturbing the byte before it
+			// only breaks that case.
+			if pos == blen-1 {
+				forged[pos-1] ^= 0xff
+				ok, err := valid(slices.Concat(forged, block))
+				forged[pos-1] ^= 0xff
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					r.emit(Retry{Offset: offset + pos, Reason: "padding only valid by coincidence"})
+					continue
+				}
+			}
+
+			unciphered[pos] = byte(i) ^ pad
+			r.emit(ByteRecovered{Offset: offset + pos, Value: unciphered[pos] ^ prev[pos]})
+			found = true
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("no valid padding for byte %d", pos)
+		}
+	}
+
+	modes.XORBytes(unciphered, prev)
+	return unciphered, nil
+}
@@ -0,0 +1,47 @@
+// Package attack implements the attacks from the challenges against oracles
+// passed in by the caller.
+package attack
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+// DecideOracle reports whether o encrypts with CBC rather than ECB, by
+// checking whether a long run of identical plaintext blocks encrypts to
+// identical ciphertext blocks. It makes a single query.
+func DecideOracle(ctx context.Context, o oracle.EncryptionOracle, opts Options) (bool, error) {
+	r := opts.start()
+	r.emit(Started{Attack: "mode detection"})
+
+	ptxt := make([]byte, 16*20)
+	ctxt, err := r.encrypt(ctx, o)(ptxt)
+	if err != nil {
+		return false, r.finish(err)
+	}
+	// Whatever the oracle adds to the input, the middle of the ciphertext
+	// lies within it.
+	if len(ctxt) < len(ptxt) || len(ctxt)%16 != 0 {
+		return false, r.finish(errors.New("ciphertext is not whole blocks covering the input"))
+	}
+	blocks := slices.Collect(slices.Chunk(ctxt, 16))
+	return !slices.Equal(blocks[len(blocks)/2], blocks[len(blocks)/2+1]), r.finish(nil)
+}
+
+// DetectECB returns the index of the first ciphertext containing a repeated
+// 16 byte block, or -1 if there is none.
+func DetectECB(ctxts [][]byte) int {
+	for i, ctxt := range ctxts {
+		seen := make(map[string]bool)
+		for block := range slices.Chunk(ctxt, 16) {
+			if seen[string(block)] {
+				return i
+			}
+			seen[string(block)] = true
+		}
+	}
+	return -1
+}
@@ -0,0 +1,32 @@
+package attack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+func TestDecideOracle(t *testing.T) {
+	rand := random.Seeded(1)
+	for range 20 {
+		o, cbc := oracle.ModeDetection(rand)
+		got, err := DecideOracle(context.Background(), oracle.EncryptFunc(o), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != cbc {
+			t.Errorf("DecideOracle = %v, want %v", got, cbc)
+		}
+	}
+}
+
+func TestDecideOracleShortCiphertext(t *testing.T) {
+	for _, n := range []int{0, 16, 48} {
+		o := oracle.EncryptFunc(func(src []byte) []byte { return make([]byte, n) })
+		if _, err := DecideOracle(context.Background(), o, Options{}); err == nil {
+			t.Errorf("%d byte ciphertext: no error", n)
+		}
+	}
+}
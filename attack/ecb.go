@@ -0,0 +1,181 @@
+package attack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+// blockSize feeds the oracle longer and longer inputs until the ciphertext
+// grows, which happens once per block. It returns the block size and how
+// many bytes the oracle adds to its input.
+func blockSize(o func([]byte) ([]byte, error)) (blen, added int, err error) {
+	ctxt, err := o(nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	base := len(ctxt)
+	for i := 1; i <= 256; i++ {
+		if ctxt, err = o(bytes.Repeat([]byte("A"), i)); err != nil {
+			return 0, 0, err
+		}
+		if n := len(ctxt); n > base {
+			return n - base, base - i, nil
+		}
+	}
+	return 0, 0, errors.New("ciphertext length never changed")
+}
+
+// ByteAtATimeECB recovers the secret an ECB oracle appends to its input, one
+// byte at a time, by lining each unknown byte up at the end of a block of
+// known bytes and matching it against every possible last byte. If
+// interrupted it returns the bytes recovered so far.
+func ByteAtATimeECB(ctx context.Context, o oracle.EncryptionOracle, opts Options) ([]byte, error) {
+	r := opts.start()
+	// The first block also counts the queries finding the block size.
+	m := newMeter()
+	encrypt := count(m, r.encrypt(ctx, o))
+
+	blen, secretLen, err := blockSize(encrypt)
+	if err != nil {
+		return nil, r.finish(err)
+	}
+	r.emit(Started{Attack: "byte-at-a-time", Length: secretLen})
+
+	probe, err := encrypt(bytes.Repeat([]byte("A"), 2*blen))
+	if err != nil {
+		return nil, r.finish(err)
+	}
+	if !slices.Equal(probe[:blen], probe[blen:2*blen]) {
+		return nil, r.finish(errors.New("oracle does not appear to use ECB"))
+	}
+
+	var decrypted []byte
+	for len(decrypted) < secretLen {
+		prefix := bytes.Repeat([]byte("A"), blen-1-len(decrypted)%blen)
+		blockIdx := len(decrypted) / blen
+		ctxt, err := encrypt(prefix)
+		if err != nil {
+			return decrypted, r.finish(err)
+		}
+		target := ctxt[blockIdx*blen : (blockIdx+1)*blen]
+
+		known := slices.Concat(prefix, decrypted)
+		guess := slices.Clone(known[len(known)-(blen-1):])
+		guess = append(guess, 0)
+
+		found := false
+		for ch := range 256 {
+			guess[blen-1] = byte(ch)
+			ctxt, err := encrypt(guess)
+			if err != nil {
+				return decrypted, r.finish(err)
+			}
+			if slices.Equal(ctxt[:blen], target) {
+				r.emit(ByteRecovered{Offset: len(decrypted), Value: byte(ch)})
+				decrypted = append(decrypted, byte(ch))
+				found = true
+				break
+			}
+		}
+		if !found {
+			return decrypted, r.finish(fmt.Errorf("no match for byte %d", len(decrypted)))
+		}
+		if len(decrypted)%blen == 0 || len(decrypted) == secretLen {
+			blockIdx := (len(decrypted) - 1) / blen
+			r.emit(m.completed(blockIdx, decrypted[blockIdx*blen:]))
+		}
+	}
+
+	return decrypted, r.finish(nil)
+}
+
+// CutAndPaste forges an admin profile from an ECB profile oracle while only
+// ever asking it to encrypt user profiles. It encrypts three profiles, each
+// chosen so a useful piece of plaintext falls on a block boundary:
+//
+//   - one ending "...&role=" followed by "user" in its own block,
+//   - one with a block reading "admin&uid=100000",
+//   - one whose length is a multiple of the block size, so its last block is
+//     pure padding.
+//
+// and glues those blocks together, ending with role=admin followed by a
+// harmless duplicate uid.
+func CutAndPaste(ctx context.Context, o oracle.EncDecOracle, opts Options) (oracle.Profile, error) {
+	r := opts.start()
+	r.emit(Started{Attack: "cut-and-paste"})
+	p, err := cutAndPaste(r.encDec(ctx, o))
+	return p, r.finish(err)
+}
+
+func cutAndPaste(o func(src []byte, enc bool) ([]byte, error)) (oracle.Profile, error) {
+	const blen = 16
+
+	// find searches over email lengths for a profile whose encoding
+	// satisfies ok, and returns its encoding and encryption.
+	find := func(domain string, uid int, ok func(encoded string) bool) (string, []byte, error) {
+		for n := 1; n <= blen; n++ {
+			p := oracle.Profile{Email: strings.Repeat("m", n) + "@" + domain, UID: uid}
+			encoded, err := p.Encode()
+			if err != nil {
+				return "", nil, err
+			}
+			if ok(encoded) {
+				ctxt, err := o([]byte(encoded), true)
+				return encoded, ctxt, err
+			}
+		}
+		return "", nil, errors.New("no email length lines the blocks up")
+	}
+
+	// admin&uid=100000 has length 16
+	adminText := "admin&uid=100000"
+	encoded, enc, err := find("bar.comadmin", 100000, func(encoded string) bool {
+		i := strings.Index(encoded, adminText)
+		return i%blen == 0
+	})
+	if err != nil {
+		return oracle.Profile{}, err
+	}
+	i := strings.Index(encoded, adminText)
+	adminBlock := enc[i : i+blen]
+
+	_, enc, err = find("bar.com", 10, func(encoded string) bool {
+		return len(encoded)%blen == 0
+	})
+	if err != nil {
+		return oracle.Profile{}, err
+	}
+	padBlock := enc[len(enc)-blen:]
+
+	_, enc, err = find("bar.com", 10, func(encoded string) bool {
+		return (len(encoded)-len("user"))%blen == 0
+	})
+	if err != nil {
+		return oracle.Profile{}, err
+	}
+	head := enc[:len(enc)-blen]
+
+	forged := slices.Concat(head, adminBlock, padBlock)
+	dec, err := o(forged, false)
+	if err != nil {
+		return oracle.Profile{}, err
+	}
+	if dec == nil {
+		return oracle.Profile{}, errors.New("oracle rejected the forged ciphertext")
+	}
+
+	var p oracle.Profile
+	if err := p.Decode(string(dec)); err != nil {
+		return oracle.Profile{}, err
+	}
+	if p.Role != oracle.Admin {
+		return p, fmt.Errorf("forged profile has role %s", p.Role)
+	}
+	return p, nil
+}
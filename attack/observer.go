@@ -0,0 +1,72 @@
+package attack
+
+import "time"
+
+// Event is something an attack reports to its Observer while it runs.
+type Event interface {
+	event()
+}
+
+// Started is sent once the attack knows how many bytes it is recovering.
+type Started struct {
+	Attack string
+	Length int
+}
+
+// QueryIssued is sent before every oracle query; N counts queries so far,
+// including this one.
+type QueryIssued struct {
+	N int
+}
+
+type ByteRecovered struct {
+	Offset int
+	Value  byte
+}
+
+// BlockCompleted is sent when a block of plaintext is recovered, with the
+// queries made and time spent on that block alone, even when other blocks
+// are worked on at the same time.
+type BlockCompleted struct {
+	Index     int
+	Plaintext []byte
+	Queries   int
+	Duration  time.Duration
+}
+
+// Retry is sent when a promising guess turns out to be wrong and the attack
+// carries on searching.
+type Retry struct {
+	Offset int
+	Reason string
+}
+
+// Finished is sent when the attack returns, with its error if any.
+type Finished struct {
+	Queries int
+	Err     error
+}
+
+func (Started) event()        {}
+func (QueryIssued) event()    {}
+func (ByteRecovered) event()  {}
+func (BlockCompleted) event() {}
+func (Retry) event()          {}
+func (Finished) event()       {}
+
+type Observer interface {
+	Observe(Event)
+}
+
+type ObserverFunc func(Event)
+
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+type silent struct{}
+
+func (silent) Observe(Event) {}
+
+// Silent discards every event.
+var Silent Observer = silent{}
@@ -0,0 +1,110 @@
+package attack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+// The recordings in testdata were made against the built in oracles with
+//
+//	cryptopals attack -seed 1 -record <name>.jsonl <name>
+//	gzip -9 <name>.jsonl
+//
+// Replaying them checks that each attack still asks exactly the queries it
+// did then and still recovers the same plaintext.
+
+func loadReplay(t *testing.T, name string) *oracle.Replay {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name+".jsonl.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp, err := oracle.ReadReplay(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp
+}
+
+// checkReplayed fails if the attack strayed from the recording.
+func checkReplayed(t *testing.T, rp *oracle.Replay) {
+	t.Helper()
+	if err := rp.Err(); err != nil {
+		t.Error(err)
+	}
+	if n := rp.Remaining(); n > 0 {
+		t.Errorf("%d recorded queries were never made", n)
+	}
+}
+
+func TestReplayByteAtATime(t *testing.T) {
+	rp := loadReplay(t, "byte-at-a-time")
+	secret, err := ByteAtATimeECB(context.Background(), rp, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := oracle.DefaultSuffix(); !bytes.Equal(secret, want) {
+		t.Errorf("recovered %q, want %q", secret, want)
+	}
+	checkReplayed(t, rp)
+}
+
+func TestReplayCutAndPaste(t *testing.T) {
+	rp := loadReplay(t, "cut-and-paste")
+	p, err := CutAndPaste(context.Background(), rp, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Role != oracle.Admin {
+		t.Errorf("forged profile %+v is not an admin", p)
+	}
+	checkReplayed(t, rp)
+}
+
+func TestReplayBitflip(t *testing.T) {
+	rp := loadReplay(t, "bitflip")
+	ptxt, err := CBCBitflip(context.Background(), rp, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !oracle.IsAdmin(ptxt) {
+		t.Errorf("forged userdata %q is not an admin's", ptxt)
+	}
+	checkReplayed(t, rp)
+}
+
+func TestReplayPadding(t *testing.T) {
+	rp := loadReplay(t, "padding")
+
+	// Seed 1 picked the seventh string for the recording, and the
+	// ciphertext is the oracle's recorded answer to it.
+	want, err := codec.StdEncoding.DecodeString(oracle.PaddingOracleStrings[6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctxt := rp.Encrypt(want)
+	if ctxt == nil {
+		t.Fatal("recording has no ciphertext for the chosen string")
+	}
+
+	ptxt, err := PaddingOracle(context.Background(), ctxt, rp, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ptxt, want) {
+		t.Errorf("recovered %q, want %q", ptxt, want)
+	}
+	checkReplayed(t, rp)
+}
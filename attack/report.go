@@ -0,0 +1,135 @@
+package attack
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ReportSchema is the version of the Report JSON layout. Fields may be added
+// without changing it; it is bumped whenever a field is renamed, removed or
+// changes meaning.
+const ReportSchema = 1
+
+// OracleProfile describes the oracle an attack ran against.
+type OracleProfile struct {
+	Name string `json:"name"`
+	// Target is where the oracle lives, such as "builtin" or the command
+	// line of a subprocess.
+	Target    string `json:"target"`
+	BlockSize int    `json:"block_size,omitempty"`
+}
+
+type BlockReport struct {
+	Index        int     `json:"index"`
+	PlaintextHex string  `json:"plaintext_hex"`
+	Queries      int     `json:"queries"`
+	Seconds      float64 `json:"seconds"`
+}
+
+// Report is the machine readable summary of one attack run.
+type Report struct {
+	Schema        int           `json:"schema"`
+	Attack        string        `json:"attack"`
+	Oracle        OracleProfile `json:"oracle"`
+	Success       bool          `json:"success"`
+	Error         string        `json:"error,omitempty"`
+	RecoveredHex  string        `json:"recovered_hex"`
+	RecoveredText string        `json:"recovered_text"`
+	Queries       int           `json:"queries"`
+	Retries       int           `json:"retries"`
+	Started       time.Time     `json:"started"`
+	Seconds       float64       `json:"seconds"`
+	// SecondsPerQuery is the mean wall time per oracle query, which includes
+	// the attack's own work between queries.
+	SecondsPerQuery float64 `json:"seconds_per_query"`
+	// OracleSeconds is the part of Seconds spent waiting on the oracle,
+	// when the caller measured it.
+	OracleSeconds float64 `json:"oracle_seconds,omitempty"`
+	// OracleRefused counts queries a throttled or failing oracle refused,
+	// and OracleRetries how many of those were tried again.
+	OracleRefused int           `json:"oracle_refused,omitempty"`
+	OracleRetries int           `json:"oracle_retries,omitempty"`
+	Blocks        []BlockReport `json:"blocks"`
+}
+
+// Recorder is an Observer that builds a Report from the events of one
+// attack.
+type Recorder struct {
+	report   Report
+	finished time.Time
+}
+
+func NewRecorder(oracle OracleProfile) *Recorder {
+	return &Recorder{
+		report: Report{
+			Schema:  ReportSchema,
+			Oracle:  oracle,
+			Started: time.Now(),
+			Blocks:  []BlockReport{},
+		},
+	}
+}
+
+func (r *Recorder) Observe(e Event) {
+	switch e := e.(type) {
+	case Started:
+		r.report.Attack = e.Attack
+	case QueryIssued:
+		r.report.Queries = e.N
+	case Retry:
+		r.report.Retries++
+	case BlockCompleted:
+		r.report.Blocks = append(r.report.Blocks, BlockReport{
+			Index:        e.Index,
+			PlaintextHex: hex.EncodeToString(e.Plaintext),
+			Queries:      e.Queries,
+			Seconds:      e.Duration.Seconds(),
+		})
+	case Finished:
+		r.finished = time.Now()
+		r.report.Queries = e.Queries
+		r.report.Success = e.Err == nil
+		if e.Err != nil {
+			r.report.Error = e.Err.Error()
+		}
+	}
+}
+
+// Report returns the report, with recovered as the attack's result.
+func (r *Recorder) Report(recovered []byte) Report {
+	report := r.report
+	report.RecoveredHex = hex.EncodeToString(recovered)
+	report.RecoveredText = string(recovered)
+
+	end := r.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	report.Seconds = end.Sub(report.Started).Seconds()
+	if report.Queries > 0 {
+		report.SecondsPerQuery = report.Seconds / float64(report.Queries)
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON.
+func (report Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+type tee []Observer
+
+func (t tee) Observe(e Event) {
+	for _, obs := range t {
+		obs.Observe(e)
+	}
+}
+
+// Tee sends every event to each of observers in turn.
+func Tee(observers ...Observer) Observer {
+	return tee(observers)
+}
@@ -0,0 +1,52 @@
+package attack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+// TestRecorderConcurrentBlocks checks that each block reports the queries
+// made for it alone: how many a block takes depends only on its bytes, so
+// working on several blocks at once must not change the counts.
+func TestRecorderConcurrentBlocks(t *testing.T) {
+	enc, valid := oracle.CBCPadding(random.Seeded(1))
+	ptxt, err := codec.StdEncoding.DecodeString(oracle.PaddingOracleStrings[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctxt := enc(ptxt)
+
+	blockQueries := func(concurrency int) map[int]int {
+		rec := NewRecorder(OracleProfile{Name: "padding", Target: "builtin"})
+		opts := Options{Observer: rec, Concurrency: concurrency}
+		if _, err := PaddingOracle(context.Background(), ctxt, oracle.PaddingFunc(valid), opts); err != nil {
+			t.Fatal(err)
+		}
+
+		report := rec.Report(nil)
+		queries := make(map[int]int)
+		var total int
+		for _, b := range report.Blocks {
+			queries[b.Index] = b.Queries
+			total += b.Queries
+		}
+		if total != report.Queries {
+			t.Errorf("concurrency %d: blocks add up to %d queries, want %d", concurrency, total, report.Queries)
+		}
+		return queries
+	}
+
+	want := blockQueries(1)
+	if len(want) != len(ctxt)/16-1 {
+		t.Fatalf("%d blocks reported, want %d", len(want), len(ctxt)/16-1)
+	}
+	for i, n := range blockQueries(4) {
+		if n != want[i] {
+			t.Errorf("block %d took %d queries alongside others, %d on its own", i, n, want[i])
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package attack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+// Options configures an attack. The zero value runs silently with no limit
+// on queries.
+type Options struct {
+	Observer Observer
+	// Budget caps the number of oracle queries; zero means no limit.
+	Budget int
+	// Concurrency is how many oracle queries an attack may have in flight
+	// at once, for attacks with independent parts such as the blocks of a
+	// padding oracle attack. The oracle must then be safe for concurrent
+	// use. Zero or one queries sequentially.
+	Concurrency int
+}
+
+// ErrNoCiphertext is returned when an encryption oracle answers with
+// nothing, as a failed query to an oracle that cannot report errors does.
+var ErrNoCiphertext = errors.New("encryption oracle returned no ciphertext")
+
+// ErrBudgetExhausted is the cause of an InterruptedError when an attack
+// runs out of queries.
+var ErrBudgetExhausted = errors.New("query budget exhausted")
+
+// InterruptedError is returned, along with whatever the attack recovered so
+// far, when an attack stops early because its context was done or its query
+// budget ran out. Cause is ErrBudgetExhausted or the context's error.
+type InterruptedError struct {
+	Cause   error
+	Queries int
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("attack interrupted after %d queries: %v", e.Queries, e.Cause)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Cause
+}
+
+// RefusedError is returned when the oracle refused a query or failed to
+// answer it, rather than answering: middleware rate limited or locked out
+// the attack, say, or the endpoint went away. Err is the oracle's error.
+type RefusedError struct {
+	Op      oracle.Op
+	Queries int
+	Err     error
+}
+
+func (e *RefusedError) Error() string {
+	return fmt.Sprintf("oracle refused %s query %d: %v", e.Op, e.Queries, e.Err)
+}
+
+func (e *RefusedError) Unwrap() error {
+	return e.Err
+}
+
+// run tracks the state shared by every attack: where events go and how many
+// queries have been made. It is safe for concurrent use.
+type run struct {
+	obs    Observer
+	budget int
+
+	mu      sync.Mutex
+	queries int
+}
+
+func (opts Options) start() *run {
+	r := &run{obs: opts.Observer, budget: opts.Budget}
+	if r.obs == nil {
+		r.obs = Silent
+	}
+	return r
+}
+
+func (r *run) emit(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.obs.Observe(e)
+}
+
+// query reserves one oracle query, or returns an InterruptedError if ctx is
+// done or the budget is spent.
+func (r *run) query(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return &InterruptedError{Cause: err, Queries: r.queries}
+	}
+	if r.budget > 0 && r.queries >= r.budget {
+		return &InterruptedError{Cause: ErrBudgetExhausted, Queries: r.queries}
+	}
+
+	r.queries++
+	r.obs.Observe(QueryIssued{N: r.queries})
+	return nil
+}
+
+// finish reports the end of the attack and passes err through.
+func (r *run) finish(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.obs.Observe(Finished{Queries: r.queries, Err: err})
+	return err
+}
+
+// refused turns the error an oracle answered a query with into a
+// RefusedError, or an InterruptedError if it gave up because ctx is done.
+func (r *run) refused(ctx context.Context, op oracle.Op, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ctx.Err() != nil {
+		return &InterruptedError{Cause: ctx.Err(), Queries: r.queries}
+	}
+	return &RefusedError{Op: op, Queries: r.queries, Err: err}
+}
+
+// meter counts the queries and time spent on one block, for its
+// BlockCompleted. Concurrent workers each keep their own.
+type meter struct {
+	queries int
+	start   time.Time
+}
+
+func newMeter() *meter {
+	return &meter{start: time.Now()}
+}
+
+// count wraps an oracle function so that each query through it counts
+// towards the block.
+func count[T any](m *meter, o func([]byte) (T, error)) func([]byte) (T, error) {
+	return func(src []byte) (T, error) {
+		m.queries++
+		return o(src)
+	}
+}
+
+// completed returns the event for the finished block and starts measuring
+// the next one.
+func (m *meter) completed(index int, ptxt []byte) BlockCompleted {
+	now := time.Now()
+	e := BlockCompleted{Index: index, Plaintext: ptxt, Queries: m.queries, Duration: now.Sub(m.start)}
+	m.queries, m.start = 0, now
+	return e
+}
+
+func (r *run) encrypt(ctx context.Context, o oracle.EncryptionOracle) func([]byte) ([]byte, error) {
+	return func(src []byte) ([]byte, error) {
+		if err := r.query(ctx); err != nil {
+			return nil, err
+		}
+		ctxt, err := oracle.Encrypt(ctx, o, src)
+		if err != nil {
+			return nil, r.refused(ctx, oracle.OpEncrypt, err)
+		}
+		if ctxt == nil {
+			return nil, ErrNoCiphertext
+		}
+		return ctxt, nil
+	}
+}
+
+func (r *run) encDec(ctx context.Context, o oracle.EncDecOracle) func([]byte, bool) ([]byte, error) {
+	encrypt := r.encrypt(ctx, o)
+	return func(src []byte, enc bool) ([]byte, error) {
+		if enc {
+			return encrypt(src)
+		}
+		if err := r.query(ctx); err != nil {
+			return nil, err
+		}
+		ptxt, err := oracle.Decrypt(ctx, o, src)
+		if err != nil {
+			return nil, r.refused(ctx, oracle.OpDecrypt, err)
+		}
+		return ptxt, nil
+	}
+}
+
+func (r *run) validPadding(ctx context.Context, o oracle.PaddingOracle) func([]byte) (bool, error) {
+	return func(src []byte) (bool, error) {
+		if err := r.query(ctx); err != nil {
+			return false, err
+		}
+		valid, err := oracle.ValidPadding(ctx, o, src)
+		if err != nil {
+			return false, r.refused(ctx, oracle.OpPadding, err)
+		}
+		return valid, nil
+	}
+}
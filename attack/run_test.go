@@ -0,0 +1,58 @@
+package attack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+func TestLockoutRefused(t *testing.T) {
+	enc, valid := oracle.CBCPadding(random.Seeded(1))
+	ptxt, err := codec.StdEncoding.DecodeString(oracle.PaddingOracleStrings[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockout := &oracle.Lockout{After: 5}
+
+	_, err = PaddingOracle(context.Background(), enc(ptxt), oracle.WrapPadding(oracle.PaddingFunc(valid), lockout.Guard), Options{})
+	var refused *RefusedError
+	if !errors.As(err, &refused) || !errors.Is(err, oracle.ErrLockedOut) {
+		t.Fatalf("error = %v, want a RefusedError for %v", err, oracle.ErrLockedOut)
+	}
+	if refused.Op != oracle.OpPadding || refused.Queries != 6 {
+		t.Errorf("refused %s query %d, want padding query 6", refused.Op, refused.Queries)
+	}
+}
+
+func TestRateLimitRefused(t *testing.T) {
+	limit := &oracle.RateLimit{Rate: 0.001, Burst: 3}
+	o := oracle.WrapEncryption(oracle.EncryptFunc(oracle.ByteAtATime(random.Seeded(1))), limit.Limit)
+
+	_, err := ByteAtATimeECB(context.Background(), o, Options{})
+	var ra *oracle.RetryAfterError
+	if !errors.Is(err, oracle.ErrRateLimited) || !errors.As(err, &ra) {
+		t.Fatalf("error = %v, want a rate limit with a retry after", err)
+	}
+}
+
+func TestRetryInterrupted(t *testing.T) {
+	retry := &oracle.Retry{Attempts: 5, Backoff: time.Minute}
+	o := oracle.WrapEncryption(oracle.EncryptFunc(oracle.ByteAtATime(random.Seeded(1))), retry.Do, oracle.Flaky{Rate: 1}.Fail)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ByteAtATimeECB(ctx, o, Options{})
+	var interrupted *InterruptedError
+	if !errors.As(err, &interrupted) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want an interruption by the deadline", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("attack took %v, still backing off after its deadline", d)
+	}
+}
@@ -0,0 +1,92 @@
+package attack
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+// Terminal renders attack progress on a single, continually redrawn line:
+// the query count and the plaintext recovered so far, with unknown bytes
+// masked.
+type Terminal struct {
+	w     io.Writer
+	width int
+	// Interval limits how often query events alone trigger a redraw.
+	Interval time.Duration
+
+	attack   string
+	ptxt     []byte
+	known    []bool
+	latest   int
+	queries  int
+	lastDraw time.Time
+}
+
+const terminalMask = '_'
+
+// NewTerminal returns a renderer that draws on w, which should be a
+// terminal, using at most width columns.
+func NewTerminal(w io.Writer, width int) *Terminal {
+	return &Terminal{w: w, width: width, Interval: 50 * time.Millisecond}
+}
+
+func (t *Terminal) Observe(e Event) {
+	switch e := e.(type) {
+	case Started:
+		t.attack = e.Attack
+		t.ptxt = make([]byte, e.Length)
+		t.known = make([]bool, e.Length)
+		t.queries = 0
+	case QueryIssued:
+		t.queries = e.N
+		if time.Since(t.lastDraw) < t.Interval {
+			return
+		}
+	case ByteRecovered:
+		if e.Offset >= 0 && e.Offset < len(t.ptxt) {
+			t.ptxt[e.Offset] = e.Value
+			t.known[e.Offset] = true
+			t.latest = e.Offset
+		}
+	case BlockCompleted, Retry:
+	case Finished:
+		t.queries = e.Queries
+		t.draw()
+		if e.Err != nil {
+			fmt.Fprintf(t.w, "\n%s failed: %v\n", t.attack, e.Err)
+		} else {
+			fmt.Fprintln(t.w)
+		}
+		return
+	}
+	t.draw()
+}
+
+func (t *Terminal) draw() {
+	t.lastDraw = time.Now()
+
+	status := fmt.Sprintf("%s %d queries ", t.attack, t.queries)
+	room := max(t.width-len(status), 8)
+
+	// Show the window of plaintext around the byte recovered most recently.
+	start := 0
+	if len(t.ptxt) > room {
+		start = min(max(t.latest-room/2, 0), len(t.ptxt)-room)
+	}
+	end := min(start+room, len(t.ptxt))
+
+	line := make([]byte, 0, end-start)
+	for i := start; i < end; i++ {
+		c := codec.PrintableByte(t.ptxt[i])
+		if !t.known[i] {
+			c = terminalMask
+		}
+		line = append(line, c)
+	}
+
+	// Return to the start of the line and clear it before redrawing.
+	fmt.Fprintf(t.w, "\r\x1b[2K%s%s", status, line)
+}
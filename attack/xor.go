@@ -0,0 +1,98 @@
+package attack
+
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/fharding1/cryptopals/score"
+)
+
+// SingleByteXOR finds the key byte that makes ctxt score best as English.
+func SingleByteXOR(ctxt []byte) (key byte, ptxt []byte, best float64) {
+	ptxt = make([]byte, len(ctxt))
+	candidate := make([]byte, len(ctxt))
+	for k := range 256 {
+		for i, c := range ctxt {
+			candidate[i] = c ^ byte(k)
+		}
+		if s := score.Score(candidate); k == 0 || s > best {
+			key, best = byte(k), s
+			copy(ptxt, candidate)
+		}
+	}
+	return key, ptxt, best
+}
+
+// RepeatingKeyXOR XORs src with key repeated to its length.
+func RepeatingKeyXOR(src, key []byte) []byte {
+	dst := make([]byte, len(src))
+	for i := range src {
+		dst[i] = src[i] ^ key[i%len(key)]
+	}
+	return dst
+}
+
+func hammingDistance(x, y []byte) int {
+	var d int
+	for i := range x {
+		d += bits.OnesCount8(x[i] ^ y[i])
+	}
+	return d
+}
+
+// keySizeScore is the mean normalized hamming distance between consecutive
+// blocks of ctxt; the right key size scores lowest.
+func keySizeScore(ctxt []byte, size int) float64 {
+	blocks := slices.Collect(slices.Chunk(ctxt, size))
+	if len(blocks[len(blocks)-1]) < size {
+		blocks = blocks[:len(blocks)-1]
+	}
+
+	var total int
+	for i := 0; i+1 < len(blocks); i++ {
+		total += hammingDistance(blocks[i], blocks[i+1])
+	}
+	return float64(total) / float64(len(blocks)-1) / float64(size)
+}
+
+// BreakRepeatingKeyXOR recovers the key of a repeating-key XOR ciphertext by
+// trying the most likely key sizes and solving each transposed column as
+// single byte XOR.
+func BreakRepeatingKeyXOR(ctxt []byte) (key, ptxt []byte) {
+	type sizeScore struct {
+		size  int
+		score float64
+	}
+
+	var sizes []sizeScore
+	for size := 2; size <= 40 && len(ctxt)/size >= 2; size++ {
+		sizes = append(sizes, sizeScore{size, keySizeScore(ctxt, size)})
+	}
+	slices.SortFunc(sizes, func(a, b sizeScore) int {
+		switch {
+		case a.score < b.score:
+			return -1
+		case a.score > b.score:
+			return 1
+		}
+		return 0
+	})
+
+	var best float64
+	for i, s := range sizes[:min(3, len(sizes))] {
+		candidate := make([]byte, s.size)
+		for col := range s.size {
+			var column []byte
+			for j := col; j < len(ctxt); j += s.size {
+				column = append(column, ctxt[j])
+			}
+			candidate[col], _, _ = SingleByteXOR(column)
+		}
+
+		dec := RepeatingKeyXOR(ctxt, candidate)
+		if sc := score.Score(dec); i == 0 || sc > best {
+			key, ptxt, best = candidate, dec, sc
+		}
+	}
+	return key, ptxt
+}
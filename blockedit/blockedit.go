@@ -0,0 +1,220 @@
+// Package blockedit holds a ciphertext split into blocks and applies the
+// edits used to forge ciphertexts by hand: swapping, duplicating, deleting,
+// XORing and overwriting blocks or single bytes, with undo.
+package blockedit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Edit is one change applied to the ciphertext, kept so it can be listed and
+// undone.
+type Edit struct {
+	Desc   string
+	before []byte
+}
+
+// Editor tracks a ciphertext and the edits made to it.
+type Editor struct {
+	ctxt      []byte
+	original  []byte
+	blockSize int
+	history   []Edit
+
+	// Submit sends a ciphertext to an oracle and describes its answer, such
+	// as the decryption or whether the padding was valid. It is nil when no
+	// oracle is configured.
+	Submit func(ctxt []byte) (string, error)
+}
+
+func New(ctxt []byte, blockSize int) (*Editor, error) {
+	if blockSize <= 0 {
+		return nil, errors.New("blockedit: block size must be positive")
+	}
+	return &Editor{
+		ctxt:      slices.Clone(ctxt),
+		original:  slices.Clone(ctxt),
+		blockSize: blockSize,
+	}, nil
+}
+
+func (e *Editor) BlockSize() int {
+	return e.blockSize
+}
+
+// Bytes returns the current ciphertext.
+func (e *Editor) Bytes() []byte {
+	return slices.Clone(e.ctxt)
+}
+
+// Blocks splits the current ciphertext into blocks. The last block is short
+// if the ciphertext is not a multiple of the block size.
+func (e *Editor) Blocks() [][]byte {
+	var blocks [][]byte
+	for i := 0; i < len(e.ctxt); i += e.blockSize {
+		blocks = append(blocks, slices.Clone(e.ctxt[i:min(i+e.blockSize, len(e.ctxt))]))
+	}
+	return blocks
+}
+
+// History returns the edits applied since the last Reset, oldest first.
+func (e *Editor) History() []Edit {
+	return slices.Clone(e.history)
+}
+
+func (e *Editor) nblocks() int {
+	return (len(e.ctxt) + e.blockSize - 1) / e.blockSize
+}
+
+func (e *Editor) block(i int) ([]byte, error) {
+	if i < 0 || i >= e.nblocks() {
+		return nil, fmt.Errorf("no block %d", i)
+	}
+	return e.ctxt[i*e.blockSize : min((i+1)*e.blockSize, len(e.ctxt))], nil
+}
+
+// apply records the ciphertext before an edit and then replaces it.
+func (e *Editor) apply(desc string, ctxt []byte) {
+	e.history = append(e.history, Edit{Desc: desc, before: e.ctxt})
+	e.ctxt = ctxt
+}
+
+// Swap exchanges blocks i and j, which must both be whole blocks.
+func (e *Editor) Swap(i, j int) error {
+	a, err := e.block(i)
+	if err != nil {
+		return err
+	}
+	b, err := e.block(j)
+	if err != nil {
+		return err
+	}
+	if len(a) != len(b) {
+		return errors.New("cannot swap a partial block")
+	}
+
+	ctxt := slices.Clone(e.ctxt)
+	copy(ctxt[i*e.blockSize:], b)
+	copy(ctxt[j*e.blockSize:], a)
+	e.apply(fmt.Sprintf("swap %d %d", i, j), ctxt)
+	return nil
+}
+
+// Duplicate inserts a copy of block i so that it becomes block at.
+func (e *Editor) Duplicate(i, at int) error {
+	b, err := e.block(i)
+	if err != nil {
+		return err
+	}
+	if at < 0 || at > e.nblocks() {
+		return fmt.Errorf("cannot insert at block %d", at)
+	}
+	if len(b) != e.blockSize && at != e.nblocks() {
+		return errors.New("a partial block can only be duplicated at the end")
+	}
+
+	pos := min(at*e.blockSize, len(e.ctxt))
+	ctxt := slices.Insert(slices.Clone(e.ctxt), pos, b...)
+	e.apply(fmt.Sprintf("dup %d %d", i, at), ctxt)
+	return nil
+}
+
+// Delete removes block i.
+func (e *Editor) Delete(i int) error {
+	b, err := e.block(i)
+	if err != nil {
+		return err
+	}
+
+	pos := i * e.blockSize
+	ctxt := slices.Delete(slices.Clone(e.ctxt), pos, pos+len(b))
+	e.apply(fmt.Sprintf("del %d", i), ctxt)
+	return nil
+}
+
+// Append adds data to the end of the ciphertext.
+func (e *Editor) Append(data []byte) {
+	e.apply(fmt.Sprintf("append %x", data), append(slices.Clone(e.ctxt), data...))
+}
+
+// XORBytes XORs data into the ciphertext starting at offset.
+func (e *Editor) XORBytes(offset int, data []byte) error {
+	if offset < 0 || offset+len(data) > len(e.ctxt) {
+		return fmt.Errorf("bytes [%d, %d) out of range", offset, offset+len(data))
+	}
+
+	ctxt := slices.Clone(e.ctxt)
+	for i, c := range data {
+		ctxt[offset+i] ^= c
+	}
+	e.apply(fmt.Sprintf("xorbyte %d %x", offset, data), ctxt)
+	return nil
+}
+
+// SetBytes overwrites the ciphertext with data starting at offset.
+func (e *Editor) SetBytes(offset int, data []byte) error {
+	if offset < 0 || offset+len(data) > len(e.ctxt) {
+		return fmt.Errorf("bytes [%d, %d) out of range", offset, offset+len(data))
+	}
+
+	ctxt := slices.Clone(e.ctxt)
+	copy(ctxt[offset:], data)
+	e.apply(fmt.Sprintf("setbyte %d %x", offset, data), ctxt)
+	return nil
+}
+
+// XORBlock XORs data, which must be no longer than the block, into block i.
+func (e *Editor) XORBlock(i int, data []byte) error {
+	b, err := e.block(i)
+	if err != nil {
+		return err
+	}
+	if len(data) > len(b) {
+		return fmt.Errorf("%d bytes do not fit in block %d", len(data), i)
+	}
+
+	if err := e.XORBytes(i*e.blockSize, data); err != nil {
+		return err
+	}
+	e.history[len(e.history)-1].Desc = fmt.Sprintf("xor %d %x", i, data)
+	return nil
+}
+
+// SetBlock overwrites block i with data, which must be no longer than the
+// block.
+func (e *Editor) SetBlock(i int, data []byte) error {
+	b, err := e.block(i)
+	if err != nil {
+		return err
+	}
+	if len(data) > len(b) {
+		return fmt.Errorf("%d bytes do not fit in block %d", len(data), i)
+	}
+
+	if err := e.SetBytes(i*e.blockSize, data); err != nil {
+		return err
+	}
+	e.history[len(e.history)-1].Desc = fmt.Sprintf("set %d %x", i, data)
+	return nil
+}
+
+// Undo reverts the most recent edit, reporting false if there is none.
+func (e *Editor) Undo() bool {
+	if len(e.history) == 0 {
+		return false
+	}
+
+	last := e.history[len(e.history)-1]
+	e.history = e.history[:len(e.history)-1]
+	e.ctxt = last.before
+	return true
+}
+
+// Reset restores the ciphertext the editor was created with and clears the
+// history.
+func (e *Editor) Reset() {
+	e.ctxt = slices.Clone(e.original)
+	e.history = nil
+}
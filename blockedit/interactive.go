@@ -0,0 +1,185 @@
+package blockedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+const help = `commands:
+  show                   print the ciphertext by block, * marking blocks changed from the original
+  swap <i> <j>           swap blocks i and j
+  dup <i> [at]           insert a copy of block i as block at, by default straight after it
+  del <i>                delete block i
+  xor <i> <hex>          XOR hex into block i
+  set <i> <hex>          overwrite the start of block i with hex
+  xorbyte <offset> <hex> XOR hex into the ciphertext at offset
+  setbyte <offset> <hex> overwrite the ciphertext at offset with hex
+  append <hex>           add hex to the end of the ciphertext
+  submit                 send the ciphertext to the oracle
+  history                list the edits made so far
+  undo                   revert the last edit
+  reset                  go back to the original ciphertext
+  hex                    print the ciphertext as a single hex string
+  quit`
+
+// Interact runs a line based editing session, reading commands from in and
+// writing results to out until quit or the end of in.
+func (e *Editor) Interact(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+
+	fmt.Fprintln(out, help)
+	e.printBlocks(out)
+	fmt.Fprint(out, "> ")
+	for scanner.Scan() {
+		cmd, rest, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		args := strings.Fields(rest)
+
+		var err error
+		edited := true
+		switch cmd {
+		case "":
+			edited = false
+		case "help":
+			fmt.Fprintln(out, help)
+			edited = false
+		case "show":
+		case "hex":
+			fmt.Fprintln(out, codec.HexEncodeToString(e.ctxt))
+			edited = false
+		case "swap":
+			var n []int
+			if n, err = ints(args, 2, "usage: swap <i> <j>"); err == nil {
+				err = e.Swap(n[0], n[1])
+			}
+		case "dup":
+			var n []int
+			switch len(args) {
+			case 1:
+				if n, err = ints(args, 1, ""); err == nil {
+					err = e.Duplicate(n[0], n[0]+1)
+				}
+			case 2:
+				if n, err = ints(args, 2, ""); err == nil {
+					err = e.Duplicate(n[0], n[1])
+				}
+			default:
+				err = errors.New("usage: dup <i> [at]")
+			}
+		case "del":
+			var n []int
+			if n, err = ints(args, 1, "usage: del <i>"); err == nil {
+				err = e.Delete(n[0])
+			}
+		case "xor", "set", "xorbyte", "setbyte":
+			var n int
+			var data []byte
+			if n, data, err = intAndHex(args, cmd); err != nil {
+				break
+			}
+			switch cmd {
+			case "xor":
+				err = e.XORBlock(n, data)
+			case "set":
+				err = e.SetBlock(n, data)
+			case "xorbyte":
+				err = e.XORBytes(n, data)
+			case "setbyte":
+				err = e.SetBytes(n, data)
+			}
+		case "append":
+			var data []byte
+			if len(args) != 1 {
+				err = errors.New("usage: append <hex>")
+			} else if data, err = codec.HexDecodeString(args[0]); err == nil {
+				e.Append(data)
+			}
+		case "submit":
+			edited = false
+			if e.Submit == nil {
+				err = errors.New("no oracle configured")
+				break
+			}
+			var answer string
+			if answer, err = e.Submit(e.Bytes()); err == nil {
+				fmt.Fprintln(out, answer)
+			}
+		case "history":
+			edited = false
+			if len(e.history) == 0 {
+				fmt.Fprintln(out, "no edits")
+			}
+			for i, edit := range e.history {
+				fmt.Fprintf(out, "%3d  %s\n", i, edit.Desc)
+			}
+		case "undo":
+			if !e.Undo() {
+				err = errors.New("nothing to undo")
+			}
+		case "reset":
+			e.Reset()
+		case "quit", "exit":
+			return nil
+		default:
+			edited = false
+			err = fmt.Errorf("unknown command %q, try help", cmd)
+		}
+
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+		} else if edited {
+			e.printBlocks(out)
+		}
+		fmt.Fprint(out, "> ")
+	}
+	return scanner.Err()
+}
+
+func ints(args []string, n int, usage string) ([]int, error) {
+	if len(args) != n {
+		return nil, errors.New(usage)
+	}
+
+	out := make([]int, n)
+	for i, arg := range args {
+		var err error
+		if out[i], err = strconv.Atoi(arg); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func intAndHex(args []string, cmd string) (int, []byte, error) {
+	if len(args) != 2 {
+		return 0, nil, fmt.Errorf("usage: %s <n> <hex>", cmd)
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := codec.HexDecodeString(args[1])
+	return n, data, err
+}
+
+func (e *Editor) printBlocks(out io.Writer) {
+	if len(e.ctxt) == 0 {
+		fmt.Fprintln(out, "empty ciphertext")
+		return
+	}
+
+	for i, b := range e.Blocks() {
+		start := i * e.blockSize
+		changed := " "
+		if start+len(b) > len(e.original) || string(e.original[start:start+len(b)]) != string(b) {
+			changed = "*"
+		}
+		fmt.Fprintf(out, "%3d%s %-*s  %s\n", i, changed, 2*e.blockSize, codec.HexEncodeToString(b), codec.Printable(b))
+	}
+}
@@ -0,0 +1,196 @@
+// Package blockgrid draws ciphertexts as a hex grid, one block per row, to
+// make block structure visible: blocks that repeat anywhere share a colour,
+// and bytes that differ between ciphertexts from the same oracle stand out.
+package blockgrid
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+// Options control how ciphertexts are laid out.
+type Options struct {
+	// BlockSize defaults to 16.
+	BlockSize int
+	// Labels name each ciphertext; missing labels default to its index.
+	Labels []string
+	// Diff highlights bytes that differ from the first ciphertext at the
+	// same offset, including bytes past its end.
+	Diff bool
+}
+
+func (opts Options) blockSize() int {
+	if opts.BlockSize <= 0 {
+		return 16
+	}
+	return opts.BlockSize
+}
+
+// block is one row of the grid.
+type block struct {
+	index int
+	data  []byte
+	// colour is an index into the palette, or -1 for a block that does not
+	// repeat.
+	colour int
+	diff   []bool
+}
+
+func layout(ctxts [][]byte, opts Options) [][]block {
+	bs := opts.blockSize()
+
+	counts := make(map[string]int)
+	for _, ctxt := range ctxts {
+		for i := 0; i < len(ctxt); i += bs {
+			counts[string(ctxt[i:min(i+bs, len(ctxt))])]++
+		}
+	}
+
+	colours := make(map[string]int)
+	grid := make([][]block, len(ctxts))
+	for n, ctxt := range ctxts {
+		for i := 0; i < len(ctxt); i += bs {
+			data := ctxt[i:min(i+bs, len(ctxt))]
+
+			colour := -1
+			if counts[string(data)] > 1 {
+				var ok bool
+				if colour, ok = colours[string(data)]; !ok {
+					colour = len(colours)
+					colours[string(data)] = colour
+				}
+			}
+
+			var diff []bool
+			if opts.Diff && n > 0 {
+				diff = make([]bool, len(data))
+				for j := range data {
+					diff[j] = i+j >= len(ctxts[0]) || ctxts[0][i+j] != data[j]
+				}
+			}
+
+			grid[n] = append(grid[n], block{index: i / bs, data: data, colour: colour, diff: diff})
+		}
+	}
+	return grid
+}
+
+func label(opts Options, n int) string {
+	if n < len(opts.Labels) {
+		return opts.Labels[n]
+	}
+	return fmt.Sprintf("ciphertext %d", n)
+}
+
+// ansiColours are background colours for repeated blocks, chosen to keep
+// black text readable.
+var ansiColours = []string{"42", "43", "44", "45", "46", "41", "102", "103", "104", "105", "106", "101"}
+
+// WriteANSI draws the grid with ANSI escape codes for a terminal.
+func WriteANSI(w io.Writer, ctxts [][]byte, opts Options) error {
+	var sb strings.Builder
+	for n, blocks := range layout(ctxts, opts) {
+		if n > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "\x1b[1m%s\x1b[0m (%d bytes)\n", label(opts, n), len(ctxts[n]))
+
+		for _, b := range blocks {
+			fmt.Fprintf(&sb, "%4d  ", b.index)
+
+			style := ""
+			if b.colour >= 0 {
+				style = "\x1b[30;" + ansiColours[b.colour%len(ansiColours)] + "m"
+			}
+
+			var text strings.Builder
+			for j, c := range b.data {
+				diff := b.diff != nil && b.diff[j]
+				if diff {
+					// Reverse video on top of any block colour.
+					fmt.Fprintf(&sb, "%s\x1b[1;7m%02x\x1b[0m", style, c)
+					fmt.Fprintf(&text, "\x1b[1;7m%c\x1b[0m", codec.PrintableByte(c))
+				} else if style != "" {
+					fmt.Fprintf(&sb, "%s%02x\x1b[0m", style, c)
+					text.WriteByte(codec.PrintableByte(c))
+				} else {
+					fmt.Fprintf(&sb, "%02x", c)
+					text.WriteByte(codec.PrintableByte(c))
+				}
+				if j != len(b.data)-1 {
+					sb.WriteByte(' ')
+				}
+			}
+
+			fmt.Fprintf(&sb, "%*s  %s\n", 3*(opts.blockSize()-len(b.data)), "", text.String())
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var htmlColours = []string{
+	"#8dd3c7", "#ffffb3", "#bebada", "#fb8072", "#80b1d3", "#fdb462",
+	"#b3de69", "#fccde5", "#d9d9d9", "#bc80bd", "#ccebc5", "#ffed6f",
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+td { padding: 1px 3px; }
+td.index { color: #888; text-align: right; padding-right: 1em; }
+td.text { padding-left: 1em; white-space: pre; }
+span.diff { font-weight: bold; outline: 2px solid #d00; }
+</style>
+</head>
+<body>
+`
+
+// WriteHTML writes the grid as a standalone HTML page.
+func WriteHTML(w io.Writer, ctxts [][]byte, opts Options) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, htmlHeader, "ciphertext blocks")
+
+	for n, blocks := range layout(ctxts, opts) {
+		fmt.Fprintf(&sb, "<h3>%s (%d bytes)</h3>\n<table>\n", html.EscapeString(label(opts, n)), len(ctxts[n]))
+
+		for _, b := range blocks {
+			style := ""
+			if b.colour >= 0 {
+				style = fmt.Sprintf(` style="background: %s"`, htmlColours[b.colour%len(htmlColours)])
+			}
+			fmt.Fprintf(&sb, "<tr%s><td class=\"index\">%d</td>", style, b.index)
+
+			var text strings.Builder
+			for j, c := range b.data {
+				hex := fmt.Sprintf("%02x", c)
+				char := html.EscapeString(string(codec.PrintableByte(c)))
+				if b.diff != nil && b.diff[j] {
+					hex = `<span class="diff">` + hex + `</span>`
+					char = `<span class="diff">` + char + `</span>`
+				}
+				fmt.Fprintf(&sb, "<td>%s</td>", hex)
+				text.WriteString(char)
+			}
+			for range opts.blockSize() - len(b.data) {
+				sb.WriteString("<td></td>")
+			}
+			fmt.Fprintf(&sb, "<td class=\"text\">%s</td></tr>\n", text.String())
+		}
+		sb.WriteString("</table>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
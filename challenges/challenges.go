@@ -0,0 +1,111 @@
+// Package challenges registers a solver and a known answer for each
+// challenge solved in this repository, so they can all be run and checked
+// in one go.
+package challenges
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// seed seeds every oracle the challenges build, so that each run sees the
+// same keys and IVs.
+const seed = 1
+
+type Challenge struct {
+	Set    int
+	Number int
+	Name   string
+	Solve  func() (string, error)
+	// Expected is the answer Solve must return, or only its beginning when
+	// Prefix is set.
+	Expected string
+	Prefix   bool
+}
+
+// ID is "<set>.<number>", numbered the same way as the files in the set
+// directories.
+func (c Challenge) ID() string {
+	return fmt.Sprintf("%d.%d", c.Set, c.Number)
+}
+
+type Status int
+
+const (
+	Pass Status = iota
+	Fail
+	// Skip means the challenge's input file is missing from data.
+	Skip
+)
+
+func (s Status) String() string {
+	switch s {
+	case Pass:
+		return "PASS"
+	case Fail:
+		return "FAIL"
+	case Skip:
+		return "SKIP"
+	default:
+		return "????"
+	}
+}
+
+type Result struct {
+	Challenge Challenge
+	Status    Status
+	Output    string
+	Err       error
+	Duration  time.Duration
+}
+
+var all []Challenge
+
+func register(c Challenge) {
+	all = append(all, c)
+}
+
+// All returns every registered challenge in set and number order.
+func All() []Challenge {
+	return all
+}
+
+func Find(id string) (Challenge, bool) {
+	for _, c := range all {
+		if c.ID() == id {
+			return c, true
+		}
+	}
+	return Challenge{}, false
+}
+
+// Run solves the challenge and checks the answer.
+func (c Challenge) Run() Result {
+	start := time.Now()
+	out, err := c.Solve()
+	r := Result{Challenge: c, Output: out, Err: err, Duration: time.Since(start)}
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		r.Status = Skip
+	case err != nil:
+		r.Status = Fail
+	case c.Prefix && strings.HasPrefix(out, c.Expected), !c.Prefix && out == c.Expected:
+		r.Status = Pass
+	default:
+		r.Status = Fail
+		r.Err = fmt.Errorf("got %q, want %q", abbreviate(out), c.Expected)
+	}
+	return r
+}
+
+func abbreviate(s string) string {
+	const max = 64
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
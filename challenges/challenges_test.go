@@ -0,0 +1,43 @@
+package challenges
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestChallenges solves every challenge, checks it against its known answer
+// and compares its whole output to testdata/<id>.golden, so that a change in
+// anything past the checked prefix shows up too. Challenges whose input is
+// missing from data are skipped.
+func TestChallenges(t *testing.T) {
+	for _, c := range All() {
+		t.Run(c.ID(), func(t *testing.T) {
+			r := c.Run()
+			if r.Status == Skip {
+				t.Skip(r.Err)
+			}
+			if r.Status != Pass {
+				t.Fatalf("%s: %v", r.Status, r.Err)
+			}
+
+			golden := filepath.Join("testdata", c.ID()+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(r.Output), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Output != string(want) {
+				t.Errorf("output differs from %s:\ngot  %q\nwant %q", golden, r.Output, want)
+			}
+		})
+	}
+}
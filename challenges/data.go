@@ -0,0 +1,48 @@
+package challenges
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+//go:embed data
+var data embed.FS
+
+// load decodes a whole challenge input file, whatever its encoding.
+func load(name string) ([]byte, error) {
+	f, err := data.Open("data/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer f.Close()
+
+	r, _, err := codec.Detect(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return io.ReadAll(r)
+}
+
+// loadLines decodes each line of a challenge input file separately.
+func loadLines(name string) ([][]byte, error) {
+	contents, err := data.ReadFile("data/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, err := codec.DetectBytes(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", name, n, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
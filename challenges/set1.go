@@ -0,0 +1,140 @@
+package challenges
+
+import (
+	"crypto/aes"
+	"errors"
+
+	"github.com/fharding1/cryptopals/attack"
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/modes"
+)
+
+const vanillaIce = "I'm back and I'm ringin' the bell"
+
+func init() {
+	register(Challenge{
+		Set: 1, Number: 1, Name: "Convert hex to base64",
+		Solve: func() (string, error) {
+			b, err := codec.HexDecodeString("49276d206b696c6c696e6720796f757220627261696e206c696b65206120706f69736f6e6f7573206d757368726f6f6d")
+			if err != nil {
+				return "", err
+			}
+			return codec.StdEncoding.EncodeToString(b), nil
+		},
+		Expected: "SSdtIGtpbGxpbmcgeW91ciBicmFpbiBsaWtlIGEgcG9pc29ub3VzIG11c2hyb29t",
+	})
+
+	register(Challenge{
+		Set: 1, Number: 2, Name: "Fixed XOR",
+		Solve: func() (string, error) {
+			x, err := codec.HexDecodeString("1c0111001f010100061a024b53535009181c")
+			if err != nil {
+				return "", err
+			}
+			y, err := codec.HexDecodeString("686974207468652062756c6c277320657965")
+			if err != nil {
+				return "", err
+			}
+			modes.XORBytes(x, y)
+			return codec.HexEncodeToString(x), nil
+		},
+		Expected: "746865206b696420646f6e277420706c6179",
+	})
+
+	register(Challenge{
+		Set: 1, Number: 3, Name: "Single-byte XOR cipher",
+		Solve: func() (string, error) {
+			ctxt, err := codec.HexDecodeString("1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736")
+			if err != nil {
+				return "", err
+			}
+			_, ptxt, _ := attack.SingleByteXOR(ctxt)
+			return string(ptxt), nil
+		},
+		Expected: "Cooking MC's like a pound of bacon",
+	})
+
+	register(Challenge{
+		Set: 1, Number: 4, Name: "Detect single-character XOR",
+		Solve: func() (string, error) {
+			ctxts, err := loadLines("4.txt")
+			if err != nil {
+				return "", err
+			}
+
+			var best []byte
+			var bestScore float64
+			for i, ctxt := range ctxts {
+				_, ptxt, s := attack.SingleByteXOR(ctxt)
+				if i == 0 || s > bestScore {
+					best, bestScore = ptxt, s
+				}
+			}
+			return string(best), nil
+		},
+		Expected: "Now that the party is jumping\n",
+	})
+
+	register(Challenge{
+		Set: 1, Number: 5, Name: "Implement repeating-key XOR",
+		Solve: func() (string, error) {
+			ptxt := []byte("Burning 'em, if you ain't quick and nimble\nI go crazy when I hear a cymbal")
+			return codec.HexEncodeToString(attack.RepeatingKeyXOR(ptxt, []byte("ICE"))), nil
+		},
+		Expected: "0b3637272a2b2e63622c2e69692a23693a2a3c6324202d623d63343c2a26226324272765272a282b2f20430a652e2c652a3124333a653e2b2027630c692b20283165286326302e27282f",
+	})
+
+	register(Challenge{
+		Set: 1, Number: 6, Name: "Break repeating-key XOR",
+		Solve: func() (string, error) {
+			ctxt, err := load("6.txt")
+			if err != nil {
+				return "", err
+			}
+			_, ptxt := attack.BreakRepeatingKeyXOR(ctxt)
+			return string(ptxt), nil
+		},
+		Expected: vanillaIce,
+		Prefix:   true,
+	})
+
+	register(Challenge{
+		Set: 1, Number: 7, Name: "AES in ECB mode",
+		Solve: func() (string, error) {
+			ctxt, err := load("7.txt")
+			if err != nil {
+				return "", err
+			}
+			if len(ctxt)%aes.BlockSize != 0 {
+				return "", errors.New("ciphertext is not a whole number of blocks")
+			}
+
+			ecb, err := modes.NewAESECB([]byte("YELLOW SUBMARINE"))
+			if err != nil {
+				return "", err
+			}
+			ptxt := make([]byte, len(ctxt))
+			ecb.HandleBytes(ptxt, ctxt, false)
+			return string(ptxt), nil
+		},
+		Expected: vanillaIce,
+		Prefix:   true,
+	})
+
+	register(Challenge{
+		Set: 1, Number: 8, Name: "Detect AES in ECB mode",
+		Solve: func() (string, error) {
+			ctxts, err := loadLines("8.txt")
+			if err != nil {
+				return "", err
+			}
+			i := attack.DetectECB(ctxts)
+			if i < 0 {
+				return "", errors.New("no ciphertext has repeated blocks")
+			}
+			return codec.HexEncodeToString(ctxts[i]), nil
+		},
+		Expected: "d880619740a8a19b7840a8a31c810a3d",
+		Prefix:   true,
+	})
+}
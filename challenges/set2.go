@@ -0,0 +1,113 @@
+package challenges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fharding1/cryptopals/attack"
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+func init() {
+	register(Challenge{
+		Set: 2, Number: 1, Name: "Implement PKCS#7 padding",
+		Solve: func() (string, error) {
+			return string(modes.PKCS7Pad([]byte("YELLOW SUBMARINE"), 20)), nil
+		},
+		Expected: "YELLOW SUBMARINE\x04\x04\x04\x04",
+	})
+
+	register(Challenge{
+		Set: 2, Number: 2, Name: "Implement CBC mode",
+		Solve: func() (string, error) {
+			ctxt, err := load("10.txt")
+			if err != nil {
+				return "", err
+			}
+
+			cbc, err := modes.NewAESCBC([]byte("YELLOW SUBMARINE"), make([]byte, 16))
+			if err != nil {
+				return "", err
+			}
+			ptxt := make([]byte, len(ctxt))
+			cbc.HandleBytes(ptxt, ctxt, false)
+			ptxt, err = modes.PKCS7Strip(ptxt, 16)
+			return string(ptxt), err
+		},
+		Expected: vanillaIce,
+		Prefix:   true,
+	})
+
+	register(Challenge{
+		Set: 2, Number: 3, Name: "An ECB/CBC detection oracle",
+		Solve: func() (string, error) {
+			const rounds = 20
+			rand := random.Seeded(seed)
+			var correct int
+			for range rounds {
+				o, cbc := oracle.ModeDetection(rand)
+				guess, err := attack.DecideOracle(context.Background(), oracle.EncryptFunc(o), attack.Options{})
+				if err != nil {
+					return "", err
+				}
+				if guess == cbc {
+					correct++
+				}
+			}
+			return fmt.Sprintf("%d/%d modes detected", correct, rounds), nil
+		},
+		Expected: "20/20 modes detected",
+	})
+
+	register(Challenge{
+		Set: 2, Number: 4, Name: "Byte-at-a-time ECB decryption (Simple)",
+		Solve: func() (string, error) {
+			secret, err := attack.ByteAtATimeECB(context.Background(), oracle.EncryptFunc(oracle.ByteAtATime(random.Seeded(seed))), attack.Options{})
+			return string(secret), err
+		},
+		Expected: "Rollin' in my 5.0\nWith my rag-top down so my hair can blow\nThe girlies on standby waving just to say hi\nDid you stop? No, I just drove by\n",
+	})
+
+	register(Challenge{
+		Set: 2, Number: 5, Name: "ECB cut-and-paste",
+		Solve: func() (string, error) {
+			p, err := attack.CutAndPaste(context.Background(), oracle.EncDecFunc(oracle.ProfileECB(random.Seeded(seed))), attack.Options{})
+			if err != nil {
+				return "", err
+			}
+			return p.Encode()
+		},
+		Expected: "email=mmmmm@bar.com&uid=100000&role=admin",
+	})
+
+	register(Challenge{
+		Set: 2, Number: 7, Name: "PKCS#7 padding validation",
+		Solve: func() (string, error) {
+			for _, bad := range []string{"ICE ICE BABY\x05\x05\x05\x05", "ICE ICE BABY\x01\x02\x03\x04"} {
+				if _, err := modes.PKCS7Strip([]byte(bad), 16); err == nil {
+					return "", fmt.Errorf("accepted bad padding %q", bad)
+				}
+			}
+
+			ptxt, err := modes.PKCS7Strip([]byte("ICE ICE BABY\x04\x04\x04\x04"), 16)
+			if err != nil {
+				return "", errors.Join(errors.New("rejected good padding"), err)
+			}
+			return string(ptxt), nil
+		},
+		Expected: "ICE ICE BABY",
+	})
+
+	register(Challenge{
+		Set: 2, Number: 8, Name: "CBC bitflipping attacks",
+		Solve: func() (string, error) {
+			ptxt, err := attack.CBCBitflip(context.Background(), oracle.EncDecFunc(oracle.Userdata(random.Seeded(seed))), attack.Options{})
+			return string(ptxt), err
+		},
+		Expected: "admin=true;",
+		Prefix:   true,
+	})
+}
@@ -0,0 +1,45 @@
+package challenges
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fharding1/cryptopals/attack"
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+func init() {
+	register(Challenge{
+		Set: 3, Number: 1, Name: "The CBC padding oracle",
+		Solve: func() (string, error) {
+			enc, dec := oracle.CBCPadding(random.Seeded(seed))
+
+			var recovered []string
+			for _, s := range oracle.PaddingOracleStrings {
+				ptxt, err := codec.StdEncoding.DecodeString(s)
+				if err != nil {
+					return "", err
+				}
+
+				dst, err := attack.PaddingOracle(context.Background(), enc(ptxt), oracle.PaddingFunc(dec), attack.Options{})
+				if err != nil {
+					return "", err
+				}
+				recovered = append(recovered, string(dst))
+			}
+			return strings.Join(recovered, "\n"), nil
+		},
+		Expected: `000000Now that the party is jumping
+000001With the bass kicked in and the Vega's are pumpin'
+000002Quick to the point, to the point, no faking
+000003Cooking MC's like a pound of bacon
+000004Burning 'em, if you ain't quick and nimble
+000005I go crazy when I hear a cymbal
+000006And a high hat with a souped up tempo
+000007I'm on a roll, it's time to go solo
+000008ollin' in my five point oh
+000009ith my rag-top down so my hair can blow`,
+	})
+}
@@ -0,0 +1,69 @@
+// Command cribdrag interactively recovers a keystream shared by the
+// ciphertexts in a file, one ciphertext per line in hex, base64 or raw form.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/cribdrag"
+	"github.com/fharding1/cryptopals/score"
+)
+
+func main() {
+	modelPath := flag.String("model", "", "score plaintext with a model built by trainmodel instead of English letter frequencies")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: cribdrag [-model file] <ciphertexts file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	model := score.Default
+	if *modelPath != "" {
+		var err error
+		if model, err = score.Load(*modelPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	var ctxts [][]byte
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		ctxt, _, err := codec.DetectBytes(scanner.Bytes())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "line %d: %v\n", line, err)
+			os.Exit(1)
+		}
+		ctxts = append(ctxts, ctxt)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	session := cribdrag.New(ctxts)
+	session.Score = model.Score
+	if err := session.Interact(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fharding1/cryptopals/analyze"
+)
+
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	in := fs.String("in", "auto", "input encoding: raw, hex, base64 or auto")
+	lines := fs.Bool("lines", false, "analyze each line of the input as a separate ciphertext")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cryptopals analyze [flags] [file]\n\nguesses what kind of cipher produced a ciphertext, and why; reads stdin with no file")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	path := "-"
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+
+	if !*lines {
+		ctxt, err := readInput(path, *in)
+		if err != nil {
+			return err
+		}
+		printReport(analyze.Analyze(ctxt))
+		return nil
+	}
+
+	ctxts, err := readCiphertexts(path)
+	if err != nil {
+		return err
+	}
+	for _, ctxt := range ctxts {
+		fmt.Printf("%s:%d\n", path, ctxt.line)
+		printReport(analyze.Analyze(ctxt.data))
+		fmt.Println()
+	}
+	return nil
+}
+
+func printReport(r analyze.Report) {
+	fmt.Printf("guess      %v\n", r.Guess)
+	for _, reason := range r.Reasons {
+		fmt.Printf("           %s\n", reason)
+	}
+	fmt.Printf("length     %d\n", r.Length)
+	fmt.Printf("entropy    %.3f bits per byte\n", r.Entropy)
+	fmt.Printf("chi-square %.1f (z %.2f)\n", r.ChiSquare, r.ChiSquareZ)
+	fmt.Printf("ic         %.3f\n", r.IndexOfCoincidence)
+	fmt.Printf("key size   %d (ic %.3f)\n", r.KeySize, r.KeySizeIC)
+
+	blens := make([]int, 0, len(r.LengthMod))
+	for blen := range r.LengthMod {
+		blens = append(blens, blen)
+	}
+	sort.Ints(blens)
+	var mods, repeats []string
+	for _, blen := range blens {
+		mods = append(mods, fmt.Sprintf("%d:%d", blen, r.LengthMod[blen]))
+		repeats = append(repeats, fmt.Sprintf("%d:%d", blen, r.RepeatedBlocks[blen]))
+	}
+	fmt.Printf("length mod %s\n", strings.Join(mods, " "))
+	fmt.Printf("repeats    %s\n", strings.Join(repeats, " "))
+}
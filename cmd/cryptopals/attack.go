@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fharding1/cryptopals/attack"
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+	"github.com/fharding1/cryptopals/token"
+	"github.com/fharding1/cryptopals/webapp"
+)
+
+// builtins are constructors for the in-process oracles attacks run against.
+type builtins struct {
+	byteAtATime func(rand io.Reader) func(src []byte) []byte
+	profile     func(rand io.Reader) func(src []byte, enc bool) []byte
+	userdata    func(rand io.Reader) func(src []byte, enc bool) []byte
+	padding     func(rand io.Reader) (func(src []byte) []byte, func(src []byte) bool)
+}
+
+var (
+	// vulnerable are the challenge oracles.
+	vulnerable = builtins{oracle.ByteAtATime, oracle.ProfileECB, oracle.Userdata, oracle.CBCPadding}
+	// hardened are their counterparts that seal authenticated tokens.
+	hardened = builtins{token.ByteAtATime, token.Profile, token.Userdata, token.CBCPadding}
+)
+
+// attackTarget is where an attack's oracle lives: a recording to replay, a
+// subprocess speaking the line protocol, an HTTP endpoint, the web app or,
+// when none of those are set, an in-process oracle from builtin built from
+// rand.
+type attackTarget struct {
+	replay  *oracle.Replay
+	proc    *oracle.Process
+	remote  *oracle.HTTPOracle
+	app     *webapp.Browser
+	builtin builtins
+	rand    io.Reader
+	ctxt    []byte
+	ctx     context.Context
+	opts    attack.Options
+	// mws wrap every oracle the attack is given.
+	mws []oracle.Middleware
+}
+
+func (t attackTarget) encryption(builtin func(src []byte) []byte) oracle.EncryptionOracle {
+	var o oracle.EncryptionOracle = oracle.EncryptFunc(builtin)
+	switch {
+	case t.replay != nil:
+		o = t.replay
+	case t.proc != nil:
+		o = t.proc.Plain()
+	case t.remote != nil:
+		o = t.remote
+	}
+	return oracle.WrapEncryption(o, t.mws...)
+}
+
+// encDec returns the target's encryption and decryption oracle; fromApp
+// picks the web app's flow for the attack.
+func (t attackTarget) encDec(builtin func(src []byte, enc bool) []byte, fromApp func(b *webapp.Browser) oracle.EncDecOracle) (oracle.EncDecOracle, error) {
+	var o oracle.EncDecOracle = oracle.EncDecFunc(builtin)
+	switch {
+	case t.app != nil:
+		o = fromApp(t.app)
+	case t.replay != nil:
+		o = t.replay
+	case t.proc != nil:
+		o = t.proc
+	case t.remote != nil:
+		return nil, errNeedsEncDec
+	}
+	return oracle.WrapEncDec(o, t.mws...), nil
+}
+
+// padding returns the target's padding oracle and the encryption oracle that
+// goes with it, which is nil for an HTTP endpoint.
+func (t attackTarget) padding(builtinEnc func(src []byte) []byte, builtinValid func(src []byte) bool) (oracle.EncryptionOracle, oracle.PaddingOracle) {
+	var enc oracle.EncryptionOracle = oracle.EncryptFunc(builtinEnc)
+	var valid oracle.PaddingOracle = oracle.PaddingFunc(builtinValid)
+	switch {
+	case t.replay != nil:
+		enc, valid = t.replay, t.replay
+	case t.proc != nil:
+		enc, valid = t.proc, t.proc
+	case t.remote != nil:
+		return nil, oracle.WrapPadding(t.remote, t.mws...)
+	}
+	return oracle.WrapEncryption(enc, t.mws...), oracle.WrapPadding(valid, t.mws...)
+}
+
+// err returns the first error talking to the target's oracle, if any, for
+// the web app, which cannot return one with each query.
+func (t attackTarget) err() error {
+	if t.app != nil {
+		return t.app.Err()
+	}
+	return nil
+}
+
+var attacks = map[string]func(t attackTarget) (string, error){
+	"byte-at-a-time": func(t attackTarget) (string, error) {
+		secret, err := attack.ByteAtATimeECB(t.ctx, t.encryption(t.builtin.byteAtATime(t.rand)), t.opts)
+		return string(secret), err
+	},
+	"cut-and-paste": func(t attackTarget) (string, error) {
+		o, err := t.encDec(t.builtin.profile(t.rand), (*webapp.Browser).Profile)
+		if err != nil {
+			return "", err
+		}
+		p, err := attack.CutAndPaste(t.ctx, o, t.opts)
+		if err != nil {
+			return "", err
+		}
+		return p.Encode()
+	},
+	"bitflip": func(t attackTarget) (string, error) {
+		o, err := t.encDec(t.builtin.userdata(t.rand), (*webapp.Browser).Userdata)
+		if err != nil {
+			return "", err
+		}
+		ptxt, err := attack.CBCBitflip(t.ctx, o, t.opts)
+		return string(ptxt), err
+	},
+	"padding": func(t attackTarget) (string, error) {
+		enc, valid := t.padding(t.builtin.padding(t.rand))
+
+		ctxt := t.ctxt
+		if ctxt == nil {
+			if enc == nil {
+				return "", errors.New("attacking a URL needs a -ctxt to decrypt")
+			}
+			s := oracle.PaddingOracleStrings[random.Intn(t.rand, len(oracle.PaddingOracleStrings))]
+			ptxt, err := codec.StdEncoding.DecodeString(s)
+			if err != nil {
+				return "", err
+			}
+			if ctxt, err = oracle.Encrypt(t.ctx, enc, ptxt); err != nil {
+				return "", err
+			}
+		}
+
+		ptxt, err := attack.PaddingOracle(t.ctx, ctxt, valid, t.opts)
+		return string(ptxt), err
+	},
+}
+
+var errNeedsEncDec = errors.New("this attack needs to both encrypt and decrypt, so cannot target a single URL; use -exec")
+
+func attackNames() []string {
+	var names []string
+	for name := range attacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runAttack(args []string) error {
+	fs := flag.NewFlagSet("attack", flag.ContinueOnError)
+	execCmd := fs.String("exec", "", "attack a subprocess speaking the line protocol instead of the built in oracle, e.g. \"cryptopals oracle padding\"")
+	seed := fs.Int64("seed", 0, "seed for the built in oracle; 0 picks one at random")
+	harden := fs.Bool("hardened", false, "attack the built in oracle's authenticated token counterpart, which every attack should fail against")
+	progress := fs.Bool("progress", false, "show live progress on stderr")
+	recordPath := fs.String("record", "", "record every oracle query and answer to this file")
+	replayPath := fs.String("replay", "", "answer oracle queries from a file written by -record; attacks that pick their own input need the same -seed")
+	logQueries := fs.Bool("log", false, "log every oracle query to stderr")
+	reportPath := fs.String("report", "", "write a JSON report of the run to this file, - for stdout")
+	budget := fs.Int("budget", 0, "stop after this many oracle queries; 0 for no limit")
+	timeout := fs.Duration("timeout", 0, "stop after this long; 0 for no limit")
+	concurrency := fs.Int("concurrency", 1, "oracle queries to have in flight at once, for attacks that can use more than one")
+	remote := fs.String("url", "", "attack an HTTP endpoint instead: the encryption oracle for byte-at-a-time, the padding validator for padding")
+	in := fs.String("in", "body", "where requests to -url carry the input: body, query or cookie")
+	name := fs.String("name", "data", "query parameter or cookie holding the input")
+	body := fs.String("body", `{"data":"{data}"}`, "request body template; {data} is replaced by the encoded input")
+	encoding := fs.String("encoding", "hex", "encoding of inputs and outputs over HTTP: hex, base64, base64url or raw")
+	field := fs.String("field", "data", "JSON field holding the output of an HTTP encryption oracle; empty for the whole body")
+	invalid := fs.String("invalid", "", "treat HTTP responses containing this text as invalid padding; by default any non-2xx status is")
+	appURL := fs.String("app", "", "attack the web app served by cryptopals webapp at this URL through its cookies, for cut-and-paste and bitflip")
+	retries := fs.Int("retries", 3, "times to retry an oracle query that was rate limited or failed")
+	backoff := fs.Duration("backoff", 100*time.Millisecond, "wait before the first retry, doubling for each after")
+	lim := limitFlags(fs)
+	ctxtHex := fs.String("ctxt", "", "ciphertext to decrypt in hex, for the padding attack; by default one is requested from the oracle")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: cryptopals attack [flags] <name>\n\nattacks: %v\n", attackNames())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	run, ok := attacks[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("no attack %q", fs.Arg(0))
+	}
+
+	if *seed == 0 {
+		*seed = random.Int63(nil)
+	}
+	t := attackTarget{builtin: vulnerable, rand: random.Seeded(*seed)}
+	if *harden {
+		t.builtin = hardened
+	}
+
+	var targets int
+	for _, flag := range []string{*remote, *execCmd, *replayPath, *appURL} {
+		if flag != "" {
+			targets++
+		}
+	}
+	if targets > 1 {
+		return errors.New("-url, -exec, -replay and -app are mutually exclusive")
+	}
+	if *appURL != "" {
+		if fs.Arg(0) != "cut-and-paste" && fs.Arg(0) != "bitflip" {
+			return errors.New("the web app only has the flows cut-and-paste and bitflip attack")
+		}
+		t.app = webapp.NewBrowser(*appURL)
+	}
+	if *replayPath != "" {
+		var err error
+		if t.replay, err = oracle.LoadReplay(*replayPath); err != nil {
+			return err
+		}
+	}
+	if *remote != "" {
+		req, err := httpRequest(*remote, *in, *name, *body, *encoding)
+		if err != nil {
+			return err
+		}
+		resp := oracle.Response{Field: *field}
+		if *invalid != "" {
+			resp.Valid = oracle.InvalidBody(*invalid)
+		}
+		t.remote = oracle.NewHTTPOracle(req, resp)
+		t.remote.Retries, t.remote.Backoff = *retries, *backoff
+	}
+
+	profile := attack.OracleProfile{Name: fs.Arg(0), Target: fmt.Sprintf("builtin, seed %d", *seed), BlockSize: 16}
+	if *harden {
+		profile.Target = fmt.Sprintf("hardened builtin, seed %d", *seed)
+	}
+	switch {
+	case *execCmd != "":
+		profile.Target = *execCmd
+	case *remote != "":
+		profile.Target = *remote
+	case *replayPath != "":
+		profile.Target = "replay of " + *replayPath
+	case *appURL != "":
+		profile.Target = "web app at " + *appURL
+	}
+	reporter := attack.NewRecorder(profile)
+
+	observers := []attack.Observer{reporter}
+	if *progress {
+		observers = append(observers, attack.NewTerminal(os.Stderr, 80))
+	}
+	t.opts.Observer = attack.Tee(observers...)
+	t.opts.Budget = *budget
+
+	// Retries go outermost, so the meter sees every attempt, and the
+	// simulated limits innermost, next to the oracle.
+	retry := &oracle.Retry{Attempts: *retries, Backoff: *backoff}
+	var meter oracle.Meter
+	t.mws = []oracle.Middleware{retry.Do, meter.Measure}
+	if *logQueries {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		t.mws = append(t.mws, oracle.Log(logger))
+	}
+
+	var recorder *oracle.Recorder
+	if *recordPath != "" {
+		f, err := os.Create(*recordPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		recorder = oracle.NewRecorder(f)
+		t.mws = append(t.mws, recorder.Record)
+	}
+	t.mws = append(t.mws, lim.middleware()...)
+	t.opts.Concurrency = *concurrency
+
+	// Interrupting stops the attack cleanly so that what it recovered so far
+	// is still printed and reported.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	t.ctx = ctx
+
+	if *ctxtHex != "" {
+		var err error
+		if t.ctxt, err = codec.HexDecodeString(*ctxtHex); err != nil {
+			return fmt.Errorf("ctxt: %w", err)
+		}
+	}
+
+	if *execCmd != "" {
+		argv := strings.Fields(*execCmd)
+		proc, err := oracle.StartProcess(argv[0], argv[1:]...)
+		if err != nil {
+			return err
+		}
+		defer proc.Close()
+		t.proc = proc
+	}
+
+	out, err := run(t)
+	err = errors.Join(err, t.err())
+	if recorder != nil {
+		err = errors.Join(err, recorder.Flush())
+	}
+	if t.replay != nil && err == nil && t.replay.Remaining() > 0 {
+		err = fmt.Errorf("%d recorded queries were never made", t.replay.Remaining())
+	}
+	if t.app != nil && err == nil {
+		// The browser now holds the forged cookie; see if the app agrees.
+		admin, aerr := t.app.Admin()
+		switch {
+		case aerr != nil:
+			err = aerr
+		case admin:
+			fmt.Fprintln(os.Stderr, "the app let the forged cookie into its admin page")
+		default:
+			err = errors.New("the app turned the forged cookie away from its admin page")
+		}
+	}
+
+	if *reportPath != "" {
+		report := reporter.Report([]byte(out))
+		stats := meter.Stats()
+		report.OracleSeconds = stats.Total.Seconds()
+		report.OracleRefused = stats.Refused
+		report.OracleRetries = retry.Retries()
+		if rerr := writeReport(*reportPath, report); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+	}
+	var interrupted *attack.InterruptedError
+	if err != nil && !errors.As(err, &interrupted) {
+		return err
+	}
+	if *reportPath != "-" {
+		fmt.Println(out)
+	}
+	return err
+}
+
+func httpRequest(url, in, name, body, encoding string) (oracle.Request, error) {
+	req := oracle.Request{URL: url, Name: name, Body: body}
+	switch in {
+	case "body":
+		req.In = oracle.InBody
+		req.Header = http.Header{"Content-Type": {"application/json"}}
+	case "query":
+		req.In = oracle.InQuery
+	case "cookie":
+		req.In = oracle.InCookie
+	default:
+		return req, fmt.Errorf("unknown input location %q", in)
+	}
+
+	switch encoding {
+	case "hex":
+		req.Encoding = codec.Hex
+	case "base64":
+		req.Encoding = codec.Base64
+	case "base64url":
+		req.Encoding = codec.Base64URL
+	case "raw":
+		req.Encoding = codec.Raw
+	default:
+		return req, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	return req, nil
+}
+
+func writeReport(path string, report attack.Report) error {
+	if path == "-" {
+		return report.WriteJSON(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fharding1/cryptopals/blockedit"
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+// blockOracle encrypts a starting plaintext and describes the oracle's
+// answer to an edited ciphertext.
+type blockOracle struct {
+	enc    func(ptxt []byte) ([]byte, error)
+	submit func(ctxt []byte) (string, error)
+}
+
+var blockOracles = map[string]func(t attackTarget) blockOracle{
+	"profile": func(t attackTarget) blockOracle {
+		var o oracle.EncDecOracle = oracle.EncDecFunc(oracle.ProfileECB(t.rand))
+		if t.proc != nil {
+			o = t.proc
+		}
+		return blockOracle{
+			enc: func(email []byte) ([]byte, error) {
+				p, err := oracle.Profile{Email: string(email), UID: 10, Role: oracle.User}.Encode()
+				if err != nil {
+					// Let the user see the oracle reject bad input rather
+					// than refusing to start.
+					p = string(email)
+				}
+				return oracle.Encrypt(context.Background(), o, []byte(p))
+			},
+			submit: func(ctxt []byte) (string, error) {
+				ptxt, err := oracle.Decrypt(context.Background(), o, ctxt)
+				if err != nil {
+					return "", err
+				}
+				if ptxt == nil {
+					return "rejected: invalid padding", nil
+				}
+				var p oracle.Profile
+				if err := p.Decode(string(ptxt)); err != nil {
+					return fmt.Sprintf("%q\nrejected: %v", ptxt, err), nil
+				}
+				return fmt.Sprintf("%q\nrole %v", ptxt, p.Role), nil
+			},
+		}
+	},
+	"userdata": func(t attackTarget) blockOracle {
+		var o oracle.EncDecOracle = oracle.EncDecFunc(oracle.Userdata(t.rand))
+		if t.proc != nil {
+			o = t.proc
+		}
+		return blockOracle{
+			enc: func(ptxt []byte) ([]byte, error) {
+				return oracle.Encrypt(context.Background(), o, ptxt)
+			},
+			submit: func(ctxt []byte) (string, error) {
+				ptxt, err := oracle.Decrypt(context.Background(), o, ctxt)
+				if err != nil {
+					return "", err
+				}
+				if ptxt == nil {
+					return "rejected: not a whole number of blocks", nil
+				}
+				return fmt.Sprintf("%q\nadmin %v", ptxt, oracle.IsAdmin(ptxt)), nil
+			},
+		}
+	},
+	"padding": func(t attackTarget) blockOracle {
+		builtinEnc, builtinValid := oracle.CBCPadding(t.rand)
+		var enc oracle.EncryptionOracle = oracle.EncryptFunc(builtinEnc)
+		var valid oracle.PaddingOracle = oracle.PaddingFunc(builtinValid)
+		if t.proc != nil {
+			enc, valid = t.proc, t.proc
+		}
+		return blockOracle{
+			enc: func(ptxt []byte) ([]byte, error) {
+				return oracle.Encrypt(context.Background(), enc, ptxt)
+			},
+			submit: func(ctxt []byte) (string, error) {
+				ok, err := oracle.ValidPadding(context.Background(), valid, ctxt)
+				switch {
+				case err != nil:
+					return "", err
+				case ok:
+					return "valid padding", nil
+				}
+				return "invalid padding", nil
+			},
+		}
+	},
+}
+
+func blockOracleNames() []string {
+	var names []string
+	for name := range blockOracles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runBlocks(args []string) error {
+	fs := flag.NewFlagSet("blocks", flag.ContinueOnError)
+	oracleName := fs.String("oracle", "", "oracle to submit edited ciphertexts to")
+	execCmd := fs.String("exec", "", "run the oracle as a subprocess speaking the line protocol instead of built in")
+	seed := fs.Int64("seed", 0, "seed for the built in oracle; 0 picks one at random")
+	ctxtHex := fs.String("ctxt", "", "ciphertext to start from in hex")
+	ptxt := fs.String("ptxt", "", "plaintext for the oracle to encrypt as the starting ciphertext; the email address for the profile oracle")
+	blockSize := fs.Int("bs", 16, "block size")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: cryptopals blocks [flags]\n\nedits a ciphertext block by block, reading commands from stdin\n\noracles: %v\n", blockOracleNames())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	var o blockOracle
+	if *oracleName != "" {
+		newOracle, ok := blockOracles[*oracleName]
+		if !ok {
+			return fmt.Errorf("no oracle %q", *oracleName)
+		}
+
+		if *seed == 0 {
+			*seed = random.Int63(nil)
+		}
+		t := attackTarget{rand: random.Seeded(*seed)}
+		if *execCmd != "" {
+			argv := strings.Fields(*execCmd)
+			proc, err := oracle.StartProcess(argv[0], argv[1:]...)
+			if err != nil {
+				return err
+			}
+			defer proc.Close()
+			t.proc = proc
+		}
+		o = newOracle(t)
+	} else if *execCmd != "" {
+		return errors.New("-exec needs -oracle to say which protocol it speaks")
+	}
+
+	var ctxt []byte
+	switch {
+	case *ctxtHex != "":
+		var err error
+		if ctxt, err = codec.HexDecodeString(*ctxtHex); err != nil {
+			return fmt.Errorf("ctxt: %w", err)
+		}
+	case o.enc != nil:
+		var err error
+		if ctxt, err = o.enc([]byte(*ptxt)); err != nil {
+			return fmt.Errorf("encrypting the starting ciphertext: %w", err)
+		}
+	default:
+		return errors.New("need -ctxt, or -oracle to encrypt a starting ciphertext")
+	}
+
+	editor, err := blockedit.New(ctxt, *blockSize)
+	if err != nil {
+		return err
+	}
+	editor.Submit = o.submit
+	return editor.Interact(os.Stdin, os.Stdout)
+}
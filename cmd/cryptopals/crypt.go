@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/modes"
+)
+
+func runCrypt(args []string, enc bool) error {
+	name, inform, outform := "decrypt", "auto", "raw"
+	if enc {
+		name, inform, outform = "encrypt", "raw", "base64"
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	mode := fs.String("mode", "cbc", "cipher mode: ecb, cbc or ctr")
+	keyHex := fs.String("key", "", "AES key in hex")
+	ivHex := fs.String("iv", "", "CBC IV, or the 8 byte CTR nonce, in hex")
+	padding := fs.String("padding", "pkcs7", "padding scheme for ecb and cbc: pkcs7 or none")
+	in := fs.String("in", "-", "input file, - for stdin")
+	out := fs.String("out", "-", "output file, - for stdout")
+	fs.StringVar(&inform, "inform", inform, "input encoding: raw, hex, base64 or auto")
+	fs.StringVar(&outform, "outform", outform, "output encoding: raw, hex or base64")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := codec.HexDecodeString(*keyHex)
+	if err != nil {
+		return fmt.Errorf("key: %w", err)
+	}
+	iv, err := codec.HexDecodeString(*ivHex)
+	if err != nil {
+		return fmt.Errorf("iv: %w", err)
+	}
+
+	m, blen, err := newMode(*mode, key, iv)
+	if err != nil {
+		return err
+	}
+
+	src, err := readInput(*in, inform)
+	if err != nil {
+		return err
+	}
+
+	if blen > 0 {
+		switch *padding {
+		case "pkcs7":
+			if enc {
+				src = modes.PKCS7Pad(src, blen)
+			}
+		case "none":
+		default:
+			return fmt.Errorf("unknown padding scheme %q", *padding)
+		}
+		if len(src)%blen != 0 {
+			return fmt.Errorf("input length %d is not a multiple of the block length %d", len(src), blen)
+		}
+	}
+
+	dst := make([]byte, len(src))
+	m.HandleBytes(dst, src, enc)
+
+	if blen > 0 && !enc && *padding == "pkcs7" {
+		if dst, err = modes.PKCS7Strip(dst, blen); err != nil {
+			return err
+		}
+	}
+
+	return writeOutput(*out, outform, dst)
+}
+
+// newMode builds the named mode. The returned block length is zero for
+// stream modes, which need no padding.
+func newMode(name string, key, iv []byte) (modes.Mode, int, error) {
+	switch name {
+	case "ecb":
+		ecb, err := modes.NewAESECB(key)
+		return &ecb, ecb.BlockSize(), err
+	case "cbc":
+		if len(iv) == 0 {
+			return nil, 0, errors.New("cbc needs an -iv")
+		}
+		cbc, err := modes.NewAESCBC(key, iv)
+		return &cbc, cbc.BlockSize(), err
+	case "ctr":
+		if len(iv) != 8 {
+			return nil, 0, errors.New("ctr needs an 8 byte -iv to use as the nonce")
+		}
+		ctr, err := modes.NewAESCTR(key, binary.BigEndian.Uint64(iv))
+		return &ctr, 0, err
+	}
+	return nil, 0, fmt.Errorf("unknown mode %q", name)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fharding1/cryptopals/blockgrid"
+	"github.com/fharding1/cryptopals/codec"
+)
+
+func runGrid(args []string) error {
+	fs := flag.NewFlagSet("grid", flag.ContinueOnError)
+	blockSize := fs.Int("bs", 16, "block size")
+	diff := fs.Bool("diff", false, "highlight bytes that differ from the first ciphertext")
+	htmlPath := fs.String("html", "", "write a standalone HTML page to this file instead of drawing in the terminal")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cryptopals grid [flags] [file...]\n\ndraws ciphertexts by block, one per line of each file in hex, base64 or raw form; reads stdin with no files")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	opts := blockgrid.Options{BlockSize: *blockSize, Diff: *diff}
+	var ctxts [][]byte
+	for _, path := range paths {
+		lines, err := readCiphertexts(path)
+		if err != nil {
+			return err
+		}
+		for _, ctxt := range lines {
+			ctxts = append(ctxts, ctxt.data)
+			opts.Labels = append(opts.Labels, fmt.Sprintf("%s:%d", path, ctxt.line))
+		}
+	}
+
+	if *htmlPath == "" {
+		return blockgrid.WriteANSI(os.Stdout, ctxts, opts)
+	}
+
+	f, err := os.Create(*htmlPath)
+	if err != nil {
+		return err
+	}
+	if err := blockgrid.WriteHTML(f, ctxts, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// lineCiphertext is a ciphertext read from a line of a file.
+type lineCiphertext struct {
+	line int
+	data []byte
+}
+
+// readCiphertexts reads one ciphertext per non-empty line of path, in
+// whichever encoding each line appears to use, along with its line number.
+func readCiphertexts(path string) ([]lineCiphertext, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var ctxts []lineCiphertext
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		ctxt, _, err := codec.DetectBytes(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		ctxts = append(ctxts, lineCiphertext{line, ctxt})
+	}
+	return ctxts, scanner.Err()
+}
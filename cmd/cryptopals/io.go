@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+// readInput reads the file at path, or stdin for "-", and decodes it from
+// form: raw, hex, base64 or auto to detect the encoding.
+func readInput(path, form string) ([]byte, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	switch form {
+	case "raw":
+	case "hex":
+		r = codec.NewHexDecoder(r)
+	case "base64":
+		r = codec.NewDecoder(codec.StdEncoding, r)
+	case "auto":
+		var err error
+		if r, _, err = codec.Detect(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown input encoding %q", form)
+	}
+
+	return io.ReadAll(r)
+}
+
+// writeOutput encodes b as form, one of raw, hex or base64, and writes it to
+// the file at path, or stdout for "-".
+func writeOutput(path, form string, b []byte) error {
+	var out []byte
+	switch form {
+	case "raw":
+		out = b
+	case "hex":
+		out = []byte(codec.HexEncodeToString(b) + "\n")
+	case "base64":
+		out = []byte(codec.StdEncoding.EncodeToString(b) + "\n")
+	default:
+		return fmt.Errorf("unknown output encoding %q", form)
+	}
+
+	if path == "-" {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(path, out, 0o644)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+// limits are the flags that make an oracle behave like a real target that
+// throttles, stalls, fails and locks out its users.
+type limits struct {
+	rate    *float64
+	burst   *int
+	latency *time.Duration
+	jitter  *time.Duration
+	fail    *float64
+	lockout *int
+}
+
+func limitFlags(fs *flag.FlagSet) limits {
+	return limits{
+		rate:    fs.Float64("rate", 0, "let the oracle answer this many queries a second, refusing the rest; 0 for no limit"),
+		burst:   fs.Int("burst", 10, "queries the oracle answers at once before -rate applies"),
+		latency: fs.Duration("latency", 0, "delay every oracle query by this long"),
+		jitter:  fs.Duration("jitter", 0, "delay every oracle query by up to this much more than -latency, at random"),
+		fail:    fs.Float64("fail", 0, "fraction of oracle queries to refuse at random, as an unreliable service would"),
+		lockout: fs.Int("lockout", 0, "refuse every query after this many invalid paddings in a row; 0 never locks out"),
+	}
+}
+
+// middleware returns the middleware the flags ask for, outermost first.
+func (l limits) middleware() []oracle.Middleware {
+	var mws []oracle.Middleware
+	if *l.rate > 0 {
+		mws = append(mws, (&oracle.RateLimit{Rate: *l.rate, Burst: max(*l.burst, 1)}).Limit)
+	}
+	if *l.latency > 0 || *l.jitter > 0 {
+		mws = append(mws, oracle.Latency{Min: *l.latency, Max: *l.latency + *l.jitter}.Delay)
+	}
+	if *l.fail > 0 {
+		mws = append(mws, oracle.Flaky{Rate: *l.fail}.Fail)
+	}
+	if *l.lockout > 0 {
+		mws = append(mws, (&oracle.Lockout{After: *l.lockout}).Guard)
+	}
+	return mws
+}
@@ -0,0 +1,64 @@
+// Command cryptopals exposes the shared cipher code and attack tooling from a
+// single binary.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"encrypt", "encrypt data with AES in ECB, CBC or CTR mode", func(args []string) error { return runCrypt(args, true) }},
+	{"decrypt", "decrypt data with AES in ECB, CBC or CTR mode", func(args []string) error { return runCrypt(args, false) }},
+	{"run", "run challenge solutions and check their answers", runChallenges},
+	{"oracle", "serve a challenge oracle over the line protocol on stdin and stdout", runOracle},
+	{"serve", "serve every challenge oracle over HTTP", runServe},
+	{"webapp", "serve a mock login app that keeps encrypted profiles in cookies", runWebapp},
+	{"attack", "run an attack against a built in oracle or a subprocess", runAttack},
+	{"blocks", "edit a ciphertext block by block and submit it to an oracle", runBlocks},
+	{"penguin", "encrypt an image's pixels to show what ECB leaks", runPenguin},
+	{"analyze", "guess what kind of cipher produced a ciphertext from its statistics", runAnalyze},
+	{"grid", "draw ciphertexts block by block, colouring repeated blocks", runGrid},
+	{"parsediff", "decode profiles under several policies to find parser differentials", runParseDiff},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cryptopals <command> [flags]\n\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nrun cryptopals <command> -h for the flags of a command")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+
+		err := cmd.run(os.Args[2:])
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "cryptopals %s: %v\n", cmd.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	usage()
+	os.Exit(2)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+// oracles builds each challenge oracle as a line protocol handler.
+var oracles = map[string]func(rand io.Reader) oracle.Handler{
+	"detect": func(rand io.Reader) oracle.Handler {
+		o, _ := oracle.ModeDetection(rand)
+		return oracle.BytesHandler(o)
+	},
+	"byte-at-a-time": func(rand io.Reader) oracle.Handler {
+		return oracle.BytesHandler(oracle.ByteAtATime(rand))
+	},
+	"profile": func(rand io.Reader) oracle.Handler {
+		return oracle.EncDecHandler(oracle.ProfileECB(rand))
+	},
+	"userdata": func(rand io.Reader) oracle.Handler {
+		return oracle.EncDecHandler(oracle.Userdata(rand))
+	},
+	"padding": func(rand io.Reader) oracle.Handler {
+		return oracle.PairHandler(oracle.CBCPadding(rand))
+	},
+}
+
+func oracleNames() []string {
+	var names []string
+	for name := range oracles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runOracle(args []string) error {
+	fs := flag.NewFlagSet("oracle", flag.ContinueOnError)
+	seed := fs.Int64("seed", 0, "seed for the oracle's keys and IVs; 0 picks one at random")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: cryptopals oracle [-seed n] <name>\n\nserves a challenge oracle over the line protocol on stdin and stdout\n\noracles: %v\n", oracleNames())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	newHandler, ok := oracles[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("no oracle %q", fs.Arg(0))
+	}
+
+	if *seed == 0 {
+		*seed = random.Int63(nil)
+	}
+	return oracle.Serve(os.Stdin, os.Stdout, newHandler(random.Seeded(*seed)))
+}
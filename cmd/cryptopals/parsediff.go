@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fharding1/cryptopals/kv"
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+func runParseDiff(args []string) error {
+	fs := flag.NewFlagSet("parsediff", flag.ContinueOnError)
+	escape := fs.String("escape", "raw", "how the encoder treats \"&\" and \"=\" in emails: raw, reject or percent")
+	strict := fs.Bool("strict", false, "encode only plain local@domain emails")
+	decode := fs.Bool("decode", false, "treat the arguments as encoded profiles rather than emails")
+	all := fs.Bool("all", false, "show inputs the decoders agree on too")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cryptopals parsediff [flags] [email...]\n\nencodes a user profile for each email and decodes it under every decoding policy,\nshowing where they disagree; uses a built in list of injection emails with none given")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	enc := oracle.ProfilePolicy{Codec: kv.Query}
+	switch *escape {
+	case "raw":
+		enc.Codec.Escape = kv.Raw
+	case "reject":
+		enc.Codec.Escape = kv.Reject
+	case "percent":
+		enc.Codec.Escape = kv.Percent
+	default:
+		return fmt.Errorf("unknown escaping %q", *escape)
+	}
+	if *strict {
+		enc.Email = oracle.StrictEmail
+	}
+
+	inputs := fs.Args()
+	if len(inputs) == 0 && !*decode {
+		inputs = oracle.InjectionEmails
+	}
+
+	var findings []oracle.Finding
+	for _, input := range inputs {
+		if *decode {
+			findings = append(findings, oracle.Finding{Encoded: input, Outcomes: oracle.Differential(input, oracle.ProfilePolicies)})
+			continue
+		}
+		encoded, err := enc.Encode(oracle.Profile{Email: input, UID: 10, Role: oracle.User})
+		if err != nil {
+			fmt.Printf("%q\n  not encoded: %v\n\n", input, err)
+			continue
+		}
+		findings = append(findings, oracle.Finding{Email: input, Encoded: encoded, Outcomes: oracle.Differential(encoded, oracle.ProfilePolicies)})
+	}
+
+	var differ, admin int
+	for _, f := range findings {
+		agree := oracle.Agree(f.Outcomes)
+		if !agree {
+			differ++
+		}
+		if f.Admin() {
+			admin++
+		}
+		if agree && !*all {
+			continue
+		}
+
+		mark := ""
+		if f.Admin() {
+			mark = "  ADMIN"
+		}
+		fmt.Printf("%q%s\n", f.Encoded, mark)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, o := range f.Outcomes {
+			if o.Err != nil {
+				fmt.Fprintf(tw, "  %s\terror: %v\n", o.Policy, o.Err)
+				continue
+			}
+			fmt.Fprintf(tw, "  %s\trole=%s uid=%d email=%q\n", o.Policy, o.Profile.Role, o.Profile.UID, o.Profile.Email)
+		}
+		tw.Flush()
+		fmt.Println()
+	}
+	fmt.Printf("%d inputs, %d differentials, %d decoded as admin\n", len(findings), differ, admin)
+	return nil
+}
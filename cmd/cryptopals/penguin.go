@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/imagecrypt"
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/random"
+)
+
+func runPenguin(args []string) error {
+	fs := flag.NewFlagSet("penguin", flag.ContinueOnError)
+	modeList := fs.String("mode", "ecb,cbc,ctr", "comma separated cipher modes to encrypt with")
+	keyHex := fs.String("key", "", "AES key in hex; random by default")
+	ivHex := fs.String("iv", "", "CBC IV, or the 8 byte CTR nonce, in hex; random by default")
+	seed := fs.Int64("seed", 0, "seed for the random key and IVs; 0 picks one at random")
+	outDir := fs.String("o", "", "directory for the output images; by default the input's directory")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cryptopals penguin [flags] <image.ppm|image.png>\n\nencrypts the pixels of an image with each mode and writes image.<mode>.<ext>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	if *seed == 0 && (*keyHex == "" || *ivHex == "") {
+		*seed = random.Int63(nil)
+		fmt.Fprintf(os.Stderr, "seed %d\n", *seed)
+	}
+	rand := random.Seeded(*seed)
+
+	key, err := codec.HexDecodeString(*keyHex)
+	if err != nil {
+		return fmt.Errorf("key: %w", err)
+	}
+	if len(key) == 0 {
+		key = random.Bytes(rand, 16)
+	}
+	iv, err := codec.HexDecodeString(*ivHex)
+	if err != nil {
+		return fmt.Errorf("iv: %w", err)
+	}
+
+	path := fs.Arg(0)
+	dir, base := filepath.Split(path)
+	if *outDir != "" {
+		dir = *outDir
+	}
+	ext := filepath.Ext(base)
+	base = strings.TrimSuffix(base, ext)
+
+	for _, mode := range strings.Split(*modeList, ",") {
+		modeIV := iv
+		if len(modeIV) == 0 {
+			modeIV = make([]byte, 16)
+			if mode == "ctr" {
+				modeIV = modeIV[:8]
+			}
+			random.Read(rand, modeIV)
+		}
+
+		m, blen, err := newMode(mode, key, modeIV)
+		if err != nil {
+			return err
+		}
+
+		out := filepath.Join(dir, base+"."+mode+ext)
+		if err := encryptImage(path, out, m, blen); err != nil {
+			return fmt.Errorf("%s: %w", mode, err)
+		}
+		fmt.Println(out)
+	}
+	return nil
+}
+
+func encryptImage(in, out string, m modes.Mode, blen int) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if _, err := imagecrypt.Encrypt(src, dst, m, blen); err != nil {
+		dst.Close()
+		return errors.Join(err, os.Remove(out))
+	}
+	return dst.Close()
+}
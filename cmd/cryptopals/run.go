@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/fharding1/cryptopals/challenges"
+)
+
+func runChallenges(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	verbose := fs.Bool("v", false, "print each challenge's output")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cryptopals run [-v] [<set>.<challenge>...]\n\nwith no challenges given, every challenge is run")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	toRun := challenges.All()
+	if fs.NArg() > 0 {
+		toRun = nil
+		for _, id := range fs.Args() {
+			c, ok := challenges.Find(id)
+			if !ok {
+				return fmt.Errorf("no challenge %s", id)
+			}
+			toRun = append(toRun, c)
+		}
+	}
+
+	counts := make(map[challenges.Status]int)
+	for _, c := range toRun {
+		r := c.Run()
+		counts[r.Status]++
+
+		fmt.Printf("%s %-5s %-40s %v\n", r.Status, c.ID(), c.Name, r.Duration.Round(time.Microsecond))
+		if r.Err != nil {
+			fmt.Printf("      %v\n", r.Err)
+		}
+		if *verbose && r.Output != "" {
+			fmt.Printf("      %q\n", r.Output)
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed, %d skipped\n", counts[challenges.Pass], counts[challenges.Fail], counts[challenges.Skip])
+	if counts[challenges.Fail] > 0 {
+		return fmt.Errorf("%d challenges failed", counts[challenges.Fail])
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	seed := fs.Int64("seed", 0, "seed for the oracles' keys and IVs; 0 picks one at random")
+	keyHex := fs.String("key", "", "AES key in hex for the byte-at-a-time, profile, userdata and padding oracles; by default each uses the challenge's")
+	secret := fs.String("secret", "", "secret the byte-at-a-time oracle appends, in hex, base64 or raw form; by default the challenge's")
+	lim := limitFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cryptopals serve [flags]\n\nserves every challenge oracle over HTTP; see the oracle package for the endpoints")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	var s oracle.Secrets
+	if *keyHex != "" {
+		key, err := codec.HexDecodeString(*keyHex)
+		if err != nil {
+			return fmt.Errorf("key: %w", err)
+		}
+		if n := len(key); n != 16 && n != 24 && n != 32 {
+			return fmt.Errorf("key: %d bytes is not an AES key length", n)
+		}
+		s.Key = key
+	}
+	if *secret != "" {
+		suffix, _, err := codec.DetectBytes([]byte(*secret))
+		if err != nil {
+			return fmt.Errorf("secret: %w", err)
+		}
+		s.Suffix = suffix
+	}
+
+	if *seed == 0 {
+		*seed = random.Int63(nil)
+	}
+	h := oracle.NewHTTPHandler(random.Seeded(*seed), s, lim.middleware()...)
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	logger.Printf("serving oracles on http://%s with seed %d", *addr, *seed)
+	return http.ListenAndServe(*addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Printf("%s %s", r.Method, r.URL)
+		h.ServeHTTP(w, r)
+	}))
+}
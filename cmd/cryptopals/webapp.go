@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/fharding1/cryptopals/random"
+	"github.com/fharding1/cryptopals/webapp"
+)
+
+func runWebapp(args []string) error {
+	fs := flag.NewFlagSet("webapp", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8081", "address to listen on")
+	seed := fs.Int64("seed", 0, "seed for the app's keys and IVs; 0 picks one at random")
+	harden := fs.Bool("hardened", false, "keep cookies in authenticated tokens rather than the challenge oracles")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cryptopals webapp [flags]\n\nserves a mock login app keeping the profile and userdata oracles' ciphertexts in cookies;\nattack it with cryptopals attack -app")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	if *seed == 0 {
+		*seed = random.Int63(nil)
+	}
+	b := vulnerable
+	if *harden {
+		b = hardened
+	}
+	rand := random.Seeded(*seed)
+	h := webapp.New(b.profile(rand), b.userdata(rand))
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	logger.Printf("serving the app on http://%s with seed %d", *addr, *seed)
+	return http.ListenAndServe(*addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Printf("%s %s", r.Method, r.URL)
+		h.ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,48 @@
+// Command trainmodel builds a byte frequency model from local text files for
+// scoring plaintext in other languages or formats.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fharding1/cryptopals/score"
+)
+
+func main() {
+	out := flag.String("o", "model.cpfm", "where to write the model")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: trainmodel [-o model] <corpus>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var corpora []io.Reader
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		corpora = append(corpora, f)
+	}
+
+	model, err := score.Train(io.MultiReader(corpora...))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := model.Save(*out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,306 @@
+package codec
+
+import "io"
+
+const (
+	stdAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	urlAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+	invalidChar = 0xff
+)
+
+// Encoding is a base64 alphabet, optionally padded with '='.
+type Encoding struct {
+	alphabet  string
+	decodeMap [256]byte
+	padded    bool
+}
+
+var (
+	StdEncoding    = newEncoding(stdAlphabet, true)
+	URLEncoding    = newEncoding(urlAlphabet, true)
+	RawStdEncoding = newEncoding(stdAlphabet, false)
+	RawURLEncoding = newEncoding(urlAlphabet, false)
+)
+
+func newEncoding(alphabet string, padded bool) *Encoding {
+	enc := &Encoding{alphabet: alphabet, padded: padded}
+	for i := range enc.decodeMap {
+		enc.decodeMap[i] = invalidChar
+	}
+	for i := 0; i < len(alphabet); i++ {
+		enc.decodeMap[alphabet[i]] = byte(i)
+	}
+	return enc
+}
+
+func (enc *Encoding) EncodedLen(n int) int {
+	if enc.padded {
+		return (n + 2) / 3 * 4
+	}
+	return (n*8 + 5) / 6
+}
+
+// DecodedLen returns the maximum number of bytes n encoded characters can
+// decode to.
+func (enc *Encoding) DecodedLen(n int) int {
+	if enc.padded {
+		return n / 4 * 3
+	}
+	return n * 6 / 8
+}
+
+// Encode writes the encoding of src to dst, which must hold at least
+// EncodedLen(len(src)) bytes.
+func (enc *Encoding) Encode(dst, src []byte) {
+	for len(src) >= 3 {
+		v := uint(src[0])<<16 | uint(src[1])<<8 | uint(src[2])
+		dst[0] = enc.alphabet[v>>18&0x3f]
+		dst[1] = enc.alphabet[v>>12&0x3f]
+		dst[2] = enc.alphabet[v>>6&0x3f]
+		dst[3] = enc.alphabet[v&0x3f]
+		src = src[3:]
+		dst = dst[4:]
+	}
+
+	if len(src) == 0 {
+		return
+	}
+
+	v := uint(src[0]) << 16
+	if len(src) == 2 {
+		v |= uint(src[1]) << 8
+	}
+	dst[0] = enc.alphabet[v>>18&0x3f]
+	dst[1] = enc.alphabet[v>>12&0x3f]
+	if len(src) == 2 {
+		dst[2] = enc.alphabet[v>>6&0x3f]
+	} else if enc.padded {
+		dst[2] = '='
+	}
+	if enc.padded {
+		dst[3] = '='
+	}
+}
+
+func (enc *Encoding) EncodeToString(src []byte) string {
+	dst := make([]byte, enc.EncodedLen(len(src)))
+	enc.Encode(dst, src)
+	return string(dst)
+}
+
+// Decode decodes src into dst, which must hold at least DecodedLen(len(src))
+// bytes. Line breaks are skipped. Characters outside the alphabet, missing or
+// misplaced padding, and non-zero trailing bits are all rejected.
+func (enc *Encoding) Decode(dst, src []byte) (int, error) {
+	d := base64Decoder{enc: enc}
+	n, err := d.decode(dst, src)
+	if err != nil {
+		return n, err
+	}
+	m, err := d.flush(dst[n:])
+	return n + m, err
+}
+
+func (enc *Encoding) DecodeString(s string) ([]byte, error) {
+	dst := make([]byte, enc.DecodedLen(len(s))+3)
+	n, err := enc.Decode(dst, []byte(s))
+	return dst[:n], err
+}
+
+// base64Decoder holds a partially read quantum between calls to decode so
+// that input can be fed to it in arbitrary chunks.
+type base64Decoder struct {
+	enc     *Encoding
+	offset  int64
+	quantum [4]byte
+	nchars  int
+	npad    int
+}
+
+func (d *base64Decoder) decode(dst, src []byte) (int, error) {
+	var n int
+	for _, c := range src {
+		if c == '\r' || c == '\n' {
+			d.offset++
+			continue
+		}
+
+		switch {
+		case d.npad > 0 && c != '=':
+			return n, PaddingError{Offset: d.offset, Reason: "data after padding"}
+		case c == '=' && d.enc.padded:
+			if d.nchars < 2 {
+				return n, PaddingError{Offset: d.offset, Reason: "padding too early in quantum"}
+			}
+			if d.nchars+d.npad == 4 {
+				return n, PaddingError{Offset: d.offset, Reason: "too much padding"}
+			}
+			d.npad++
+		default:
+			v := d.enc.decodeMap[c]
+			if v == invalidChar {
+				return n, InvalidCharError{Offset: d.offset, Char: c}
+			}
+			d.quantum[d.nchars] = v
+			d.nchars++
+		}
+		d.offset++
+
+		if d.nchars == 4 {
+			if err := d.emit(dst[n:]); err != nil {
+				return n, err
+			}
+			n += 3
+			d.nchars = 0
+		}
+	}
+	return n, nil
+}
+
+// flush decodes whatever is left once the input is exhausted.
+func (d *base64Decoder) flush(dst []byte) (int, error) {
+	switch {
+	case d.nchars == 0 && d.npad == 0:
+		return 0, nil
+	case d.npad > 0 && d.nchars+d.npad < 4:
+		return 0, PaddingError{Offset: d.offset, Reason: "incomplete padding"}
+	case d.npad == 0 && d.enc.padded:
+		return 0, PaddingError{Offset: d.offset, Reason: "missing padding"}
+	case d.nchars == 1:
+		return 0, PaddingError{Offset: d.offset, Reason: "truncated quantum"}
+	}
+
+	if err := d.emit(dst); err != nil {
+		return 0, err
+	}
+	n := d.nchars - 1
+	d.nchars, d.npad = 0, 0
+	return n, nil
+}
+
+// emit writes the bytes held by the current quantum to dst.
+func (d *base64Decoder) emit(dst []byte) error {
+	var v uint
+	for i := range 4 {
+		v <<= 6
+		if i < d.nchars {
+			v |= uint(d.quantum[i])
+		}
+	}
+
+	switch d.nchars {
+	case 2:
+		if v&0xffff != 0 {
+			return PaddingError{Offset: d.offset, Reason: "non-zero trailing bits"}
+		}
+	case 3:
+		if v&0xff != 0 {
+			return PaddingError{Offset: d.offset, Reason: "non-zero trailing bits"}
+		}
+	}
+
+	out := [3]byte{byte(v >> 16), byte(v >> 8), byte(v)}
+	copy(dst, out[:d.nchars-1])
+	return nil
+}
+
+type base64Encoder struct {
+	enc   *Encoding
+	w     io.Writer
+	extra []byte
+	buf   [1024]byte
+	err   error
+}
+
+// NewEncoder returns a writer that base64 encodes everything written to it
+// before passing it on to w. Close must be called to flush the final partial
+// quantum.
+func NewEncoder(enc *Encoding, w io.Writer) io.WriteCloser {
+	return &base64Encoder{enc: enc, w: w, extra: make([]byte, 0, 3)}
+}
+
+func (e *base64Encoder) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	var n int
+	if len(e.extra) > 0 {
+		take := min(len(p), 3-len(e.extra))
+		e.extra = append(e.extra, p[:take]...)
+		p = p[take:]
+		n += take
+		if len(e.extra) < 3 {
+			return n, nil
+		}
+		e.enc.Encode(e.buf[:], e.extra)
+		if _, e.err = e.w.Write(e.buf[:4]); e.err != nil {
+			return n, e.err
+		}
+		e.extra = e.extra[:0]
+	}
+
+	for len(p) >= 3 {
+		chunk := min(len(p), len(e.buf)/4*3) / 3 * 3
+		e.enc.Encode(e.buf[:], p[:chunk])
+		if _, e.err = e.w.Write(e.buf[:chunk/3*4]); e.err != nil {
+			return n, e.err
+		}
+		n += chunk
+		p = p[chunk:]
+	}
+
+	e.extra = append(e.extra, p...)
+	return n + len(p), nil
+}
+
+func (e *base64Encoder) Close() error {
+	if e.err == nil && len(e.extra) > 0 {
+		m := e.enc.EncodedLen(len(e.extra))
+		e.enc.Encode(e.buf[:], e.extra)
+		_, e.err = e.w.Write(e.buf[:m])
+		e.extra = e.extra[:0]
+	}
+	return e.err
+}
+
+type base64Reader struct {
+	r   io.Reader
+	d   base64Decoder
+	in  [1024]byte
+	buf [1024]byte
+	out []byte
+	err error
+}
+
+// NewDecoder returns a reader that decodes base64 read from r.
+func NewDecoder(enc *Encoding, r io.Reader) io.Reader {
+	return &base64Reader{r: r, d: base64Decoder{enc: enc}}
+}
+
+func (b *base64Reader) Read(p []byte) (int, error) {
+	for len(b.out) == 0 && b.err == nil {
+		n, err := b.r.Read(b.in[:])
+		m, derr := b.d.decode(b.buf[:], b.in[:n])
+		if derr == nil && err == io.EOF {
+			var k int
+			k, derr = b.d.flush(b.buf[m:])
+			m += k
+		}
+		b.out = b.buf[:m]
+		switch {
+		case derr != nil:
+			b.err = derr
+		case err != nil:
+			b.err = err
+		}
+	}
+
+	n := copy(p, b.out)
+	b.out = b.out[n:]
+	if len(b.out) == 0 && b.err != nil {
+		return n, b.err
+	}
+	return n, nil
+}
@@ -0,0 +1,33 @@
+// Package codec implements hex and base64 encodings from scratch, with
+// streaming encoders and decoders that report the exact offset of any invalid
+// input.
+package codec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOddLength is returned when hex input ends halfway through a byte.
+var ErrOddLength = errors.New("codec: odd length hex input")
+
+// InvalidCharError reports a byte that is not part of the encoding's
+// alphabet.
+type InvalidCharError struct {
+	Offset int64
+	Char   byte
+}
+
+func (e InvalidCharError) Error() string {
+	return fmt.Sprintf("codec: invalid character %q at offset %d", e.Char, e.Offset)
+}
+
+// PaddingError reports missing, misplaced or malformed base64 padding.
+type PaddingError struct {
+	Offset int64
+	Reason string
+}
+
+func (e PaddingError) Error() string {
+	return fmt.Sprintf("codec: bad padding at offset %d: %s", e.Offset, e.Reason)
+}
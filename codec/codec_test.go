@@ -0,0 +1,103 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+var encodings = []struct {
+	name string
+	enc  *Encoding
+	std  *base64.Encoding
+}{
+	{"std", StdEncoding, base64.StdEncoding.Strict()},
+	{"url", URLEncoding, base64.URLEncoding.Strict()},
+	{"rawstd", RawStdEncoding, base64.RawStdEncoding.Strict()},
+	{"rawurl", RawURLEncoding, base64.RawURLEncoding.Strict()},
+}
+
+func FuzzHex(f *testing.F) {
+	for _, s := range []string{"", "00", "49276d206b696c6c696e67", "DEADbeef", "0", "0g", "zz", "0 1"} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		encoded := HexEncodeToString(data)
+		if want := hex.EncodeToString(data); encoded != want {
+			t.Fatalf("HexEncodeToString(%x) = %q, want %q", data, encoded, want)
+		}
+		decoded, err := HexDecodeString(encoded)
+		if err != nil || !bytes.Equal(decoded, data) {
+			t.Fatalf("HexDecodeString(%q) = %x, %v, want %x", encoded, decoded, err, data)
+		}
+
+		var buf bytes.Buffer
+		NewHexEncoder(&buf).Write(data)
+		if buf.String() != encoded {
+			t.Fatalf("NewHexEncoder wrote %q, want %q", buf.String(), encoded)
+		}
+		streamed, err := io.ReadAll(NewHexDecoder(iotest.OneByteReader(&buf)))
+		if err != nil || !bytes.Equal(streamed, data) {
+			t.Fatalf("NewHexDecoder read %x, %v, want %x", streamed, err, data)
+		}
+
+		// Treated as input, data must decode exactly when encoding/hex says it
+		// does, and to the same bytes.
+		got, err := HexDecodeString(string(data))
+		want, wantErr := hex.DecodeString(string(data))
+		if (err != nil) != (wantErr != nil) {
+			t.Fatalf("HexDecodeString(%q) error %v, encoding/hex says %v", data, err, wantErr)
+		}
+		if err == nil && !bytes.Equal(got, want) {
+			t.Fatalf("HexDecodeString(%q) = %x, encoding/hex says %x", data, got, want)
+		}
+	})
+}
+
+func FuzzBase64(f *testing.F) {
+	for _, s := range []string{"", "f", "fo", "foo", "Zm9v", "Zm8=", "Zg==", "Zm9=", "Zg=", "Z===", "Zm\n9v", "-_+/", "Zg==Zg=="} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, e := range encodings {
+			encoded := e.enc.EncodeToString(data)
+			if want := e.std.EncodeToString(data); encoded != want {
+				t.Fatalf("%s: EncodeToString(%x) = %q, want %q", e.name, data, encoded, want)
+			}
+			decoded, err := e.enc.DecodeString(encoded)
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Fatalf("%s: DecodeString(%q) = %x, %v, want %x", e.name, encoded, decoded, err, data)
+			}
+
+			var buf bytes.Buffer
+			w := NewEncoder(e.enc, &buf)
+			w.Write(data)
+			w.Close()
+			if buf.String() != encoded {
+				t.Fatalf("%s: NewEncoder wrote %q, want %q", e.name, buf.String(), encoded)
+			}
+			streamed, err := io.ReadAll(NewDecoder(e.enc, iotest.OneByteReader(&buf)))
+			if err != nil || !bytes.Equal(streamed, data) {
+				t.Fatalf("%s: NewDecoder read %x, %v, want %x", e.name, streamed, err, data)
+			}
+
+			got, err := e.enc.DecodeString(string(data))
+			want, wantErr := e.std.DecodeString(string(data))
+			if (err != nil) != (wantErr != nil) {
+				t.Fatalf("%s: DecodeString(%q) error %v, encoding/base64 says %v", e.name, data, err, wantErr)
+			}
+			if err == nil && !bytes.Equal(got, want) {
+				t.Fatalf("%s: DecodeString(%q) = %x, encoding/base64 says %x", e.name, data, got, want)
+			}
+		}
+	})
+}
+
+func TestPrintable(t *testing.T) {
+	if got, want := Printable([]byte("a b\x00~\x7f\n\xff")), "a b.~..."; got != want {
+		t.Errorf("Printable = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Format is the encoding Detect believes some input to be in.
+type Format int
+
+const (
+	Raw Format = iota
+	Hex
+	Base64
+	Base64URL
+	WrappedBase64
+)
+
+func (f Format) String() string {
+	switch f {
+	case Raw:
+		return "raw"
+	case Hex:
+		return "hex"
+	case Base64:
+		return "base64"
+	case Base64URL:
+		return "base64url"
+	case WrappedBase64:
+		return "wrapped base64"
+	default:
+		return "unknown"
+	}
+}
+
+// sniffLen is how much input Detect looks at before deciding.
+const sniffLen = 4096
+
+type charClass uint8
+
+const (
+	classHex charClass = 1 << iota
+	classStd
+	classURL
+)
+
+var charClasses = func() (classes [256]charClass) {
+	for i := 0; i < len(stdAlphabet); i++ {
+		classes[stdAlphabet[i]] |= classStd
+		classes[urlAlphabet[i]] |= classURL
+	}
+	for _, c := range []byte("0123456789abcdefABCDEF") {
+		classes[c] |= classHex
+	}
+	classes['='] |= classStd | classURL
+	return classes
+}()
+
+// sniff classifies sample. complete reports whether sample is the whole
+// input, in which case unpadded base64 can be told apart from padded base64.
+func sniff(sample []byte, complete bool) (Format, *Encoding) {
+	sample = bytes.TrimRight(sample, "\r\n")
+	if len(sample) == 0 {
+		return Raw, nil
+	}
+
+	classes := classHex | classStd | classURL
+	var nchars, nlines int
+	for _, c := range sample {
+		if c == '\r' || c == '\n' {
+			if c == '\n' {
+				nlines++
+			}
+			continue
+		}
+		classes &= charClasses[c]
+		nchars++
+	}
+
+	padded := !complete || bytes.IndexByte(sample, '=') >= 0 || nchars%4 == 0
+	var format Format
+	var enc *Encoding
+	switch {
+	case classes&classHex != 0 && nchars%2 == 0:
+		return Hex, nil
+	case classes&classStd != 0:
+		format, enc = Base64, StdEncoding
+		if !padded {
+			enc = RawStdEncoding
+		}
+		if nlines > 0 {
+			format = WrappedBase64
+		}
+	case classes&classURL != 0:
+		format, enc = Base64URL, URLEncoding
+		if !padded {
+			enc = RawURLEncoding
+		}
+	default:
+		return Raw, nil
+	}
+
+	// Plenty of plain words are made of base64 characters; one that does
+	// not decode, such as one whose length is 1 mod 4, is not base64.
+	if complete {
+		if _, err := enc.Decode(make([]byte, enc.DecodedLen(len(sample))+3), sample); err != nil {
+			return Raw, nil
+		}
+	}
+	return format, enc
+}
+
+// Detect guesses the encoding of the data in r and returns a reader yielding
+// the decoded bytes. Anything that is not cleanly hex or base64 is passed
+// through untouched as Raw.
+func Detect(r io.Reader) (io.Reader, Format, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	sample, err := br.Peek(sniffLen)
+	complete := err == io.EOF
+	if err != nil && !complete && err != bufio.ErrBufferFull {
+		return nil, Raw, err
+	}
+
+	format, enc := sniff(sample, complete)
+	switch format {
+	case Hex:
+		return NewHexDecoder(&lineStripper{r: br}), format, nil
+	case Base64, Base64URL, WrappedBase64:
+		return NewDecoder(enc, br), format, nil
+	}
+	return br, format, nil
+}
+
+// DetectBytes is Detect for input that is already in memory, such as a single
+// line of a file.
+func DetectBytes(b []byte) ([]byte, Format, error) {
+	r, format, err := Detect(bytes.NewReader(b))
+	if err != nil {
+		return nil, format, err
+	}
+	dec, err := io.ReadAll(r)
+	return dec, format, err
+}
+
+// lineStripper drops line breaks so that wrapped hex can be decoded.
+type lineStripper struct {
+	r io.Reader
+}
+
+func (l *lineStripper) Read(p []byte) (int, error) {
+	for {
+		n, err := l.r.Read(p)
+		var m int
+		for _, c := range p[:n] {
+			if c != '\r' && c != '\n' {
+				p[m] = c
+				m++
+			}
+		}
+		if m > 0 || err != nil {
+			return m, err
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectBytes(t *testing.T) {
+	tests := []struct {
+		in     string
+		format Format
+		out    string
+	}{
+		{"", Raw, ""},
+		{"666f6f", Hex, "foo"},
+		{"666F6F\n626172\n", Hex, "foobar"},
+		{"Zm9vYmFy", Base64, "foobar"},
+		{"Zm9vYg==", Base64, "foob"},
+		{"Zm9vYg", Base64, "foob"},
+		{"Zm9v\nYmFy\n", WrappedBase64, "foobar"},
+		{"-_-_", Base64URL, "\xfb\xff\xbf"},
+		{"hello world", Raw, "hello world"},
+		// Base64 characters, but five of them cannot be base64.
+		{"hello", Raw, "hello"},
+		// So can a word whose last character leaves stray bits set.
+		{"Zm9vYh", Raw, "Zm9vYh"},
+	}
+	for _, tt := range tests {
+		out, format, err := DetectBytes([]byte(tt.in))
+		if err != nil {
+			t.Errorf("DetectBytes(%q): %v", tt.in, err)
+			continue
+		}
+		if format != tt.format || !bytes.Equal(out, []byte(tt.out)) {
+			t.Errorf("DetectBytes(%q) = %q as %v, want %q as %v", tt.in, out, format, tt.out, tt.format)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package codec
+
+import "io"
+
+const hexDigits = "0123456789abcdef"
+
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func HexEncodedLen(n int) int { return n * 2 }
+
+func HexDecodedLen(n int) int { return n / 2 }
+
+// HexEncode writes the lowercase hex encoding of src to dst and returns the
+// number of bytes written.
+func HexEncode(dst, src []byte) int {
+	for i, b := range src {
+		dst[i*2] = hexDigits[b>>4]
+		dst[i*2+1] = hexDigits[b&0x0f]
+	}
+	return len(src) * 2
+}
+
+func HexEncodeToString(src []byte) string {
+	dst := make([]byte, HexEncodedLen(len(src)))
+	HexEncode(dst, src)
+	return string(dst)
+}
+
+// HexDecode decodes src into dst. Upper and lower case digits are accepted;
+// anything else, including whitespace, is an InvalidCharError.
+func HexDecode(dst, src []byte) (int, error) {
+	var d hexDecoder
+	n, err := d.decode(dst, src)
+	if err != nil {
+		return n, err
+	}
+	return n, d.flush()
+}
+
+func HexDecodeString(s string) ([]byte, error) {
+	src := []byte(s)
+	n, err := HexDecode(src, src)
+	return src[:n], err
+}
+
+type hexDecoder struct {
+	offset  int64
+	high    byte
+	pending bool
+}
+
+func (d *hexDecoder) decode(dst, src []byte) (int, error) {
+	var n int
+	for _, c := range src {
+		v, ok := fromHexChar(c)
+		if !ok {
+			return n, InvalidCharError{Offset: d.offset, Char: c}
+		}
+		d.offset++
+
+		if !d.pending {
+			d.high = v
+			d.pending = true
+			continue
+		}
+		dst[n] = d.high<<4 | v
+		d.pending = false
+		n++
+	}
+	return n, nil
+}
+
+func (d *hexDecoder) flush() error {
+	if d.pending {
+		return ErrOddLength
+	}
+	return nil
+}
+
+type hexEncoder struct {
+	w   io.Writer
+	buf [1024]byte
+}
+
+// NewHexEncoder returns a writer that hex encodes everything written to it
+// before passing it on to w.
+func NewHexEncoder(w io.Writer) io.Writer {
+	return &hexEncoder{w: w}
+}
+
+func (e *hexEncoder) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		chunk := min(len(p), len(e.buf)/2)
+		m := HexEncode(e.buf[:], p[:chunk])
+		if _, err := e.w.Write(e.buf[:m]); err != nil {
+			return n, err
+		}
+		n += chunk
+		p = p[chunk:]
+	}
+	return n, nil
+}
+
+type hexReader struct {
+	r   io.Reader
+	d   hexDecoder
+	in  [1024]byte
+	out []byte
+	err error
+}
+
+// NewHexDecoder returns a reader that decodes hex read from r.
+func NewHexDecoder(r io.Reader) io.Reader {
+	return &hexReader{r: r}
+}
+
+func (h *hexReader) Read(p []byte) (int, error) {
+	for len(h.out) == 0 && h.err == nil {
+		n, err := h.r.Read(h.in[:])
+		m, derr := h.d.decode(h.in[:n], h.in[:n])
+		h.out = h.in[:m]
+		switch {
+		case derr != nil:
+			h.err = derr
+		case err == io.EOF:
+			h.err = h.d.flush()
+			if h.err == nil {
+				h.err = io.EOF
+			}
+		case err != nil:
+			h.err = err
+		}
+	}
+
+	n := copy(p, h.out)
+	h.out = h.out[n:]
+	if len(h.out) == 0 && h.err != nil {
+		return n, h.err
+	}
+	return n, nil
+}
@@ -0,0 +1,19 @@
+package codec
+
+// PrintableByte returns c if it is printable ASCII, and '.' otherwise.
+func PrintableByte(c byte) byte {
+	if c < ' ' || c > '~' {
+		return '.'
+	}
+	return c
+}
+
+// Printable returns b with every byte that is not printable ASCII replaced
+// by '.', for showing plaintexts and raw blocks on a terminal.
+func Printable(b []byte) string {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		out[i] = PrintableByte(c)
+	}
+	return string(out)
+}
@@ -0,0 +1,183 @@
+// Package cribdrag recovers a keystream shared by several ciphertexts, as with
+// a many-time pad or CTR mode under a fixed nonce, by sliding guessed
+// plaintext across each ciphertext and scoring what that implies for the
+// others.
+package cribdrag
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/fharding1/cryptopals/score"
+)
+
+// Candidate is the result of placing a crib in one ciphertext at one offset.
+type Candidate struct {
+	Ctxt   int
+	Offset int
+	// Keystream is the keystream the placement implies, starting at Offset.
+	Keystream []byte
+	// Plaintexts holds what every ciphertext decrypts to under Keystream, or
+	// nil where a ciphertext is too short to reach Offset.
+	Plaintexts [][]byte
+	Score      float64
+}
+
+// Session tracks the keystream recovered so far for a set of ciphertexts.
+type Session struct {
+	ctxts     [][]byte
+	keystream []byte
+	known     []bool
+
+	// Score rates implied plaintext; higher is better. It defaults to
+	// score.Score.
+	Score func([]byte) float64
+}
+
+func New(ctxts [][]byte) *Session {
+	var longest int
+	for _, ctxt := range ctxts {
+		longest = max(longest, len(ctxt))
+	}
+
+	return &Session{
+		ctxts:     ctxts,
+		keystream: make([]byte, longest),
+		known:     make([]bool, longest),
+		Score:     score.Score,
+	}
+}
+
+func (s *Session) Ciphertexts() [][]byte {
+	return s.ctxts
+}
+
+// Drag slides crib across every ciphertext and returns each placement that is
+// consistent with the locked keystream, best first.
+func (s *Session) Drag(crib []byte) []Candidate {
+	var candidates []Candidate
+	for i, ctxt := range s.ctxts {
+		for offset := 0; offset+len(crib) <= len(ctxt); offset++ {
+			c, ok := s.place(i, offset, crib)
+			if ok {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return candidates
+}
+
+func (s *Session) place(ctxtIdx, offset int, crib []byte) (Candidate, bool) {
+	ctxt := s.ctxts[ctxtIdx]
+	keystream := make([]byte, len(crib))
+	for i := range crib {
+		keystream[i] = ctxt[offset+i] ^ crib[i]
+		if s.known[offset+i] && s.keystream[offset+i] != keystream[i] {
+			return Candidate{}, false
+		}
+	}
+
+	c := Candidate{
+		Ctxt:       ctxtIdx,
+		Offset:     offset,
+		Keystream:  keystream,
+		Plaintexts: make([][]byte, len(s.ctxts)),
+	}
+
+	var total float64
+	var scored int
+	for i, other := range s.ctxts {
+		if offset >= len(other) {
+			continue
+		}
+		n := min(len(keystream), len(other)-offset)
+		ptxt := make([]byte, n)
+		for j := range ptxt {
+			ptxt[j] = other[offset+j] ^ keystream[j]
+		}
+		c.Plaintexts[i] = ptxt
+		if i != ctxtIdx {
+			total += s.Score(ptxt)
+			scored++
+		}
+	}
+	if scored > 0 {
+		c.Score = total / float64(scored)
+	}
+	return c, true
+}
+
+// Lock records keystream bytes as known, starting at offset.
+func (s *Session) Lock(offset int, keystream []byte) error {
+	if offset < 0 || offset+len(keystream) > len(s.keystream) {
+		return fmt.Errorf("keystream [%d, %d) out of range", offset, offset+len(keystream))
+	}
+
+	copy(s.keystream[offset:], keystream)
+	for i := range keystream {
+		s.known[offset+i] = true
+	}
+	return nil
+}
+
+// LockPlaintext locks the keystream implied by ciphertext ctxtIdx decrypting
+// to ptxt at offset.
+func (s *Session) LockPlaintext(ctxtIdx, offset int, ptxt []byte) error {
+	if ctxtIdx < 0 || ctxtIdx >= len(s.ctxts) {
+		return fmt.Errorf("no ciphertext %d", ctxtIdx)
+	}
+
+	ctxt := s.ctxts[ctxtIdx]
+	if offset < 0 || offset+len(ptxt) > len(ctxt) {
+		return errors.New("plaintext runs past the end of the ciphertext")
+	}
+
+	keystream := make([]byte, len(ptxt))
+	for i := range ptxt {
+		keystream[i] = ctxt[offset+i] ^ ptxt[i]
+	}
+	return s.Lock(offset, keystream)
+}
+
+// Unlock forgets n keystream bytes starting at offset.
+func (s *Session) Unlock(offset, n int) {
+	for i := max(offset, 0); i < min(offset+n, len(s.known)); i++ {
+		s.keystream[i] = 0
+		s.known[i] = false
+	}
+}
+
+// Keystream returns the keystream recovered so far and which of its bytes are
+// known.
+func (s *Session) Keystream() ([]byte, []bool) {
+	return slices.Clone(s.keystream), slices.Clone(s.known)
+}
+
+// Plaintexts decrypts every ciphertext with the known keystream, replacing
+// bytes that cannot be recovered yet with mask.
+func (s *Session) Plaintexts(mask byte) [][]byte {
+	ptxts := make([][]byte, len(s.ctxts))
+	for i, ctxt := range s.ctxts {
+		ptxt := make([]byte, len(ctxt))
+		for j := range ctxt {
+			if s.known[j] {
+				ptxt[j] = ctxt[j] ^ s.keystream[j]
+			} else {
+				ptxt[j] = mask
+			}
+		}
+		ptxts[i] = ptxt
+	}
+	return ptxts
+}
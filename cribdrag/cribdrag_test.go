@@ -0,0 +1,110 @@
+package cribdrag
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fharding1/cryptopals/random"
+)
+
+var ptxts = []string{
+	"We attack at dawn from the north side of the river",
+	"Send more supplies to the camp before the winter",
+}
+
+// twoTimePad encrypts ptxts with one keystream.
+func twoTimePad(t *testing.T) (*Session, []byte) {
+	t.Helper()
+	keystream := random.Bytes(random.Seeded(1), 64)
+	var ctxts [][]byte
+	for _, p := range ptxts {
+		ctxt := []byte(p)
+		for i := range ctxt {
+			ctxt[i] ^= keystream[i]
+		}
+		ctxts = append(ctxts, ctxt)
+	}
+	return New(ctxts), keystream
+}
+
+func TestDrag(t *testing.T) {
+	s, _ := twoTimePad(t)
+	crib := []byte(" the ")
+	offset := strings.Index(ptxts[0], string(crib))
+
+	candidates := s.Drag(crib)
+	if want := len(ptxts[0]) - len(crib) + 1 + len(ptxts[1]) - len(crib) + 1; len(candidates) != want {
+		t.Fatalf("%d candidates, want one per placement, %d", len(candidates), want)
+	}
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].Score > candidates[i-1].Score {
+			t.Fatalf("candidates not sorted best first at %d", i)
+		}
+	}
+
+	for _, c := range candidates {
+		if c.Ctxt == 0 && c.Offset == offset {
+			if got, want := string(c.Plaintexts[1]), ptxts[1][offset:offset+len(crib)]; got != want {
+				t.Errorf("crib in ciphertext 0 at %d implies %q in ciphertext 1, want %q", offset, got, want)
+			}
+			return
+		}
+	}
+	t.Errorf("no candidate for the crib in ciphertext 0 at %d", offset)
+}
+
+func TestLockPlaintext(t *testing.T) {
+	s, keystream := twoTimePad(t)
+	crib := "attack at dawn"
+	offset := strings.Index(ptxts[0], crib)
+	if err := s.LockPlaintext(0, offset, []byte(crib)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.Plaintexts('*')
+	for i, p := range ptxts {
+		want := strings.Repeat("*", offset) + p[offset:offset+len(crib)] + strings.Repeat("*", len(p)-offset-len(crib))
+		if string(got[i]) != want {
+			t.Errorf("plaintext %d = %q, want %q", i, got[i], want)
+		}
+	}
+
+	ks, known := s.Keystream()
+	for i := range known {
+		locked := i >= offset && i < offset+len(crib)
+		if known[i] != locked {
+			t.Fatalf("keystream byte %d known = %v, want %v", i, known[i], locked)
+		}
+		if locked && ks[i] != keystream[i] {
+			t.Errorf("keystream byte %d = %#x, want %#x", i, ks[i], keystream[i])
+		}
+	}
+
+	// Placements over the locked bytes must agree with them: only the crib
+	// where it really is in ciphertext 0 is left.
+	for _, c := range s.Drag([]byte(crib)) {
+		overlaps := c.Offset < offset+len(crib) && c.Offset+len(crib) > offset
+		if overlaps && (c.Ctxt != 0 || c.Offset != offset || !bytes.Equal(c.Keystream, keystream[offset:offset+len(crib)])) {
+			t.Errorf("placement in ciphertext %d at %d contradicts the locked keystream", c.Ctxt, c.Offset)
+		}
+	}
+
+	s.Unlock(offset, 3)
+	if got := s.Plaintexts('*')[1][offset : offset+4]; string(got) != "***"+ptxts[1][offset+3:offset+4] {
+		t.Errorf("after unlocking 3 bytes, plaintext 1 reads %q", got)
+	}
+}
+
+func TestLockErrors(t *testing.T) {
+	s, _ := twoTimePad(t)
+	if err := s.Lock(len(ptxts[0])-1, []byte("ab")); err == nil {
+		t.Error("Lock past the longest ciphertext succeeded")
+	}
+	if err := s.LockPlaintext(2, 0, []byte("a")); err == nil {
+		t.Error("LockPlaintext of a missing ciphertext succeeded")
+	}
+	if err := s.LockPlaintext(1, len(ptxts[1])-1, []byte("ab")); err == nil {
+		t.Error("LockPlaintext past the end of its ciphertext succeeded")
+	}
+}
@@ -0,0 +1,143 @@
+package cribdrag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+const (
+	maxShown = 10
+	mask     = '_'
+)
+
+const help = `commands:
+  show                      print every plaintext, unknown bytes as _
+  key                       print the keystream, unknown bytes as ??
+  drag <crib>               slide crib (the rest of the line) across every ciphertext
+  lock <n>                  lock candidate n from the last drag
+  set <ctxt> <offset> <text> lock the keystream making ciphertext ctxt read text at offset
+  unlock <offset> <n>       forget n keystream bytes from offset
+  quit`
+
+// Interact runs a line based crib dragging session, reading commands from in
+// and writing results to out until quit or the end of in.
+func (s *Session) Interact(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	var last []Candidate
+
+	fmt.Fprintln(out, help)
+	fmt.Fprint(out, "> ")
+	for scanner.Scan() {
+		cmd, rest, _ := strings.Cut(scanner.Text(), " ")
+		args := strings.Fields(rest)
+
+		var err error
+		switch cmd {
+		case "":
+		case "help":
+			fmt.Fprintln(out, help)
+		case "show":
+			s.printPlaintexts(out)
+		case "key":
+			s.printKeystream(out)
+		case "drag":
+			if rest == "" {
+				err = fmt.Errorf("usage: drag <crib>")
+				break
+			}
+			last = s.Drag([]byte(rest))
+			printCandidates(out, last)
+		case "lock":
+			var n int
+			if len(args) != 1 {
+				err = fmt.Errorf("usage: lock <n>")
+			} else if n, err = strconv.Atoi(args[0]); err == nil {
+				if n < 0 || n >= len(last) {
+					err = fmt.Errorf("no candidate %d", n)
+				} else {
+					err = s.Lock(last[n].Offset, last[n].Keystream)
+					last = nil
+				}
+			}
+			if err == nil {
+				s.printPlaintexts(out)
+			}
+		case "set":
+			fields := strings.SplitN(rest, " ", 3)
+			if len(fields) != 3 {
+				err = fmt.Errorf("usage: set <ctxt> <offset> <text>")
+				break
+			}
+			var ctxtIdx, offset int
+			if ctxtIdx, err = strconv.Atoi(fields[0]); err != nil {
+				break
+			}
+			if offset, err = strconv.Atoi(fields[1]); err != nil {
+				break
+			}
+			if err = s.LockPlaintext(ctxtIdx, offset, []byte(fields[2])); err == nil {
+				s.printPlaintexts(out)
+			}
+		case "unlock":
+			var offset, n int
+			if len(args) != 2 {
+				err = fmt.Errorf("usage: unlock <offset> <n>")
+			} else if offset, err = strconv.Atoi(args[0]); err == nil {
+				if n, err = strconv.Atoi(args[1]); err == nil {
+					s.Unlock(offset, n)
+					s.printPlaintexts(out)
+				}
+			}
+		case "quit", "exit":
+			return nil
+		default:
+			err = fmt.Errorf("unknown command %q, try help", cmd)
+		}
+
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+		fmt.Fprint(out, "> ")
+	}
+	return scanner.Err()
+}
+
+func (s *Session) printPlaintexts(out io.Writer) {
+	for i, ptxt := range s.Plaintexts(mask) {
+		fmt.Fprintf(out, "%3d  %s\n", i, codec.Printable(ptxt))
+	}
+}
+
+func (s *Session) printKeystream(out io.Writer) {
+	keystream, known := s.Keystream()
+	var sb strings.Builder
+	for i, b := range keystream {
+		if known[i] {
+			fmt.Fprintf(&sb, "%02x", b)
+		} else {
+			sb.WriteString("??")
+		}
+	}
+	fmt.Fprintln(out, sb.String())
+}
+
+func printCandidates(out io.Writer, candidates []Candidate) {
+	if len(candidates) == 0 {
+		fmt.Fprintln(out, "no consistent placements")
+		return
+	}
+
+	for n, c := range candidates[:min(len(candidates), maxShown)] {
+		fmt.Fprintf(out, "[%d] ctxt %d offset %d score %.2f\n", n, c.Ctxt, c.Offset, c.Score)
+		for i, ptxt := range c.Plaintexts {
+			if ptxt != nil {
+				fmt.Fprintf(out, "      %3d  %s\n", i, codec.Printable(ptxt))
+			}
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/fharding1/cryptopals
+
+go 1.23
@@ -0,0 +1,195 @@
+// Package imagecrypt encrypts the pixel data of an image while leaving it a
+// valid image, so the structure a cipher mode leaks can be seen: the classic
+// ECB penguin.
+package imagecrypt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/fharding1/cryptopals/modes"
+)
+
+// Format is an image file format the package can encrypt.
+type Format int
+
+const (
+	PPM Format = iota
+	PNG
+)
+
+func (f Format) String() string {
+	switch f {
+	case PPM:
+		return "ppm"
+	case PNG:
+		return "png"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+// Detect reports the format of an image from its first bytes.
+func Detect(b []byte) (Format, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte("P6")):
+		return PPM, nil
+	case bytes.HasPrefix(b, pngMagic):
+		return PNG, nil
+	}
+	return 0, errors.New("imagecrypt: not a binary PPM or PNG image")
+}
+
+// EncryptPixels encrypts pix with m. Block modes, with blen > 0, pad the
+// pixels with PKCS#7 and the result is cut back to len(pix) so it still fits
+// the image; the lost padding makes the output impossible to decrypt, which
+// does not matter for looking at it.
+func EncryptPixels(pix []byte, m modes.Mode, blen int) []byte {
+	src := pix
+	if blen > 0 {
+		src = modes.PKCS7Pad(pix, blen)
+	}
+
+	dst := make([]byte, len(src))
+	m.HandleBytes(dst, src, true)
+	return dst[:len(pix)]
+}
+
+// Encrypt reads a PPM or PNG image from r, encrypts its pixel data with m and
+// writes an image of the same format and size to w. PPM headers are copied
+// byte for byte. PNG pixels are encrypted as 8 bit RGB, the same layout as a
+// PPM, and written back fully opaque.
+func Encrypt(r io.Reader, w io.Writer, m modes.Mode, blen int) (Format, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(pngMagic))
+	if err != nil && !(err == io.EOF && len(magic) >= 2) {
+		return 0, fmt.Errorf("imagecrypt: reading header: %w", err)
+	}
+
+	format, err := Detect(magic)
+	if err != nil {
+		return 0, err
+	}
+
+	switch format {
+	case PPM:
+		err = encryptPPM(br, w, m, blen)
+	case PNG:
+		err = encryptPNG(br, w, m, blen)
+	}
+	return format, err
+}
+
+func encryptPPM(r *bufio.Reader, w io.Writer, m modes.Mode, blen int) error {
+	header, width, height, maxval, err := readPPMHeader(r)
+	if err != nil {
+		return err
+	}
+
+	size := width * height * 3
+	if maxval > 255 {
+		size *= 2
+	}
+	pix := make([]byte, size)
+	if _, err := io.ReadFull(r, pix); err != nil {
+		return fmt.Errorf("imagecrypt: reading pixels: %w", err)
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(EncryptPixels(pix, m, blen))
+	return err
+}
+
+// readPPMHeader reads a binary PPM header up to and including the single
+// whitespace byte before the pixels, returning it verbatim along with the
+// values it holds.
+func readPPMHeader(r *bufio.Reader) (header []byte, width, height, maxval int, err error) {
+	var fields [4]int
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	header = append(header, magic...)
+
+	for i := 1; i < len(fields); i++ {
+		// Skip whitespace and comments before each number.
+		var c byte
+		for {
+			if c, err = r.ReadByte(); err != nil {
+				return nil, 0, 0, 0, fmt.Errorf("imagecrypt: reading PPM header: %w", err)
+			}
+			header = append(header, c)
+			if c == '#' {
+				line, err := r.ReadBytes('\n')
+				header = append(header, line...)
+				if err != nil {
+					return nil, 0, 0, 0, fmt.Errorf("imagecrypt: reading PPM header: %w", err)
+				}
+			} else if !isSpace(c) {
+				break
+			}
+		}
+
+		for {
+			if c < '0' || c > '9' {
+				return nil, 0, 0, 0, fmt.Errorf("imagecrypt: unexpected %q in PPM header", c)
+			}
+			fields[i] = fields[i]*10 + int(c-'0')
+			if fields[i] > 1<<20 {
+				return nil, 0, 0, 0, errors.New("imagecrypt: PPM dimensions too large")
+			}
+
+			if c, err = r.ReadByte(); err != nil {
+				return nil, 0, 0, 0, fmt.Errorf("imagecrypt: reading PPM header: %w", err)
+			}
+			header = append(header, c)
+			if isSpace(c) {
+				break
+			}
+		}
+	}
+
+	width, height, maxval = fields[1], fields[2], fields[3]
+	if maxval == 0 || maxval > 65535 {
+		return nil, 0, 0, 0, fmt.Errorf("imagecrypt: invalid PPM maxval %d", maxval)
+	}
+	return header, width, height, maxval, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func encryptPNG(r io.Reader, w io.Writer, m modes.Mode, blen int) error {
+	img, err := png.Decode(r)
+	if err != nil {
+		return fmt.Errorf("imagecrypt: %w", err)
+	}
+
+	bounds := img.Bounds()
+	pix := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pix = append(pix, c.R, c.G, c.B)
+		}
+	}
+
+	pix = EncryptPixels(pix, m, blen)
+
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for i := range bounds.Dx() * bounds.Dy() {
+		copy(out.Pix[i*4:], pix[i*3:i*3+3])
+		out.Pix[i*4+3] = 0xff
+	}
+	return png.Encode(w, out)
+}
@@ -0,0 +1,332 @@
+// Package kv encodes structs as key=value strings like the ones the
+// challenges build by hand: "email=foo@bar.com&uid=10&role=user" for the
+// profile oracle and "comment1=cooking%20MCs;userdata=..." for the userdata
+// oracle.
+//
+// Each exported field is one pair, in struct order. The key is the field's
+// kv tag, or its name if it has none, and a tag of "-" skips the field.
+// Fields may be strings, booleans, integers, or any type implementing
+// encoding.TextMarshaler and encoding.TextUnmarshaler.
+package kv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Escaping is how a Codec treats values containing its separators.
+type Escaping int
+
+const (
+	// Raw writes values as they are, so a value can inject pairs of its
+	// own. Decoding splits each pair at its first Assign.
+	Raw Escaping = iota
+	// Reject refuses to encode values containing a separator, and to decode
+	// pairs with more than one Assign.
+	Reject
+	// Percent writes '%', the separators, spaces and unprintable bytes as %XX
+	// and decodes them back.
+	Percent
+)
+
+func (e Escaping) String() string {
+	switch e {
+	case Raw:
+		return "raw"
+	case Reject:
+		return "reject"
+	case Percent:
+		return "percent"
+	default:
+		return "Escaping(" + strconv.Itoa(int(e)) + ")"
+	}
+}
+
+// Duplicates is how a Codec decodes a key given more than once.
+type Duplicates int
+
+const (
+	// LastWins sets the field again for each repeat.
+	LastWins Duplicates = iota
+	// FirstWins ignores repeats.
+	FirstWins
+	// RejectDuplicates fails on the first repeat.
+	RejectDuplicates
+)
+
+func (d Duplicates) String() string {
+	switch d {
+	case LastWins:
+		return "last-wins"
+	case FirstWins:
+		return "first-wins"
+	case RejectDuplicates:
+		return "reject-duplicates"
+	default:
+		return "Duplicates(" + strconv.Itoa(int(d)) + ")"
+	}
+}
+
+// Codec is a key=value format.
+type Codec struct {
+	// Sep separates pairs, and Assign a key from its value.
+	Sep, Assign string
+	Escape      Escaping
+	Dups        Duplicates
+}
+
+var (
+	// Query is the profile oracle's format: "&" between pairs and
+	// separators rejected.
+	Query = Codec{Sep: "&", Assign: "=", Escape: Reject}
+	// Cookie is the userdata oracle's format: ";" between pairs and
+	// separators percent-encoded.
+	Cookie = Codec{Sep: ";", Assign: "=", Escape: Percent}
+)
+
+// Marshal encodes v, a struct or a pointer to one, with Query.
+func Marshal(v any) (string, error) {
+	return Query.Marshal(v)
+}
+
+// Unmarshal decodes s into v, a pointer to a struct, with Query.
+func Unmarshal(s string, v any) error {
+	return Query.Unmarshal(s, v)
+}
+
+var (
+	textMarshaler   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+type field struct {
+	key   string
+	index int
+}
+
+func fields(t reflect.Type) []field {
+	var fs []field
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key := f.Name
+		if tag, ok := f.Tag.Lookup("kv"); ok {
+			if tag == "-" {
+				continue
+			}
+			key = tag
+		}
+		fs = append(fs, field{key: key, index: i})
+	}
+	return fs
+}
+
+// Marshal encodes v, a struct or a pointer to one.
+func (c Codec) Marshal(v any) (string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", errors.New("kv: Marshal of nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return "", fmt.Errorf("kv: Marshal of non-struct %s", rv.Type())
+	}
+
+	var b strings.Builder
+	for i, f := range fields(rv.Type()) {
+		value, err := format(rv.Field(f.index))
+		if err != nil {
+			return "", fmt.Errorf("kv: %s: %w", f.key, err)
+		}
+		value, err = c.escape(value)
+		if err != nil {
+			return "", fmt.Errorf("kv: %s: %w", f.key, err)
+		}
+
+		if i > 0 {
+			b.WriteString(c.Sep)
+		}
+		b.WriteString(f.key)
+		b.WriteString(c.Assign)
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+// Unmarshal decodes s into v, a pointer to a struct. Every key must name a
+// field, and c.Dups decides what a repeated key does.
+func (c Codec) Unmarshal(s string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("kv: Unmarshal needs a non-nil pointer to a struct, not %T", v)
+	}
+	rv = rv.Elem()
+
+	byKey := make(map[string]int)
+	for _, f := range fields(rv.Type()) {
+		byKey[f.key] = f.index
+	}
+
+	if s == "" {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(s, c.Sep) {
+		key, value, err := c.split(pair)
+		if err != nil {
+			return err
+		}
+		i, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("kv: unknown key %q", key)
+		}
+		if seen[key] {
+			switch c.Dups {
+			case FirstWins:
+				continue
+			case RejectDuplicates:
+				return fmt.Errorf("kv: duplicate key %q", key)
+			}
+		}
+		seen[key] = true
+		if err := parse(rv.Field(i), value); err != nil {
+			return fmt.Errorf("kv: %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (c Codec) split(pair string) (key, value string, err error) {
+	key, value, ok := strings.Cut(pair, c.Assign)
+	if !ok {
+		return "", "", fmt.Errorf("kv: pair %q has no %q", pair, c.Assign)
+	}
+	if c.Escape != Raw && strings.Contains(value, c.Assign) {
+		return "", "", fmt.Errorf("kv: pair %q has more than one %q", pair, c.Assign)
+	}
+	if c.Escape == Percent {
+		if value, err = unescape(value); err != nil {
+			return "", "", fmt.Errorf("kv: %s: %w", key, err)
+		}
+	}
+	return key, value, nil
+}
+
+func (c Codec) escape(value string) (string, error) {
+	switch c.Escape {
+	case Reject:
+		for _, sep := range []string{c.Sep, c.Assign} {
+			if strings.Contains(value, sep) {
+				return "", fmt.Errorf("value %q contains %q", value, sep)
+			}
+		}
+		return value, nil
+	case Percent:
+		return c.percent(value), nil
+	}
+	return value, nil
+}
+
+func (c Codec) percent(value string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		if ch == '%' || ch <= ' ' || ch >= 0x7f || strings.IndexByte(c.Sep, ch) >= 0 || strings.IndexByte(c.Assign, ch) >= 0 {
+			b.WriteByte('%')
+			b.WriteByte(hex[ch>>4])
+			b.WriteByte(hex[ch&0xf])
+			continue
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+func unescape(value string) (string, error) {
+	if !strings.Contains(value, "%") {
+		return value, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+2 >= len(value) {
+			return "", fmt.Errorf("truncated escape in %q", value)
+		}
+		n, err := strconv.ParseUint(value[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("bad escape %q", value[i:i+3])
+		}
+		b.WriteByte(byte(n))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func format(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshaler) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshaler) {
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func parse(v reflect.Value, s string) error {
+	if v.Addr().Type().Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package kv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type level int
+
+func (l level) MarshalText() ([]byte, error) {
+	switch l {
+	case 0:
+		return []byte("user"), nil
+	case 1:
+		return []byte("admin"), nil
+	}
+	return nil, errors.New("bad level")
+}
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "user":
+		*l = 0
+	case "admin":
+		*l = 1
+	default:
+		return errors.New("bad level")
+	}
+	return nil
+}
+
+type profile struct {
+	Email  string `kv:"email"`
+	UID    int    `kv:"uid"`
+	Role   level  `kv:"role"`
+	Secret string `kv:"-"`
+	Admin  bool
+	Count  uint8
+	hidden string
+}
+
+var semicolon = Codec{Sep: ";", Assign: "="}
+
+func TestMarshal(t *testing.T) {
+	p := profile{Email: "foo@bar.com", UID: 10, Role: 1, Secret: "s", Admin: true, Count: 3, hidden: "h"}
+	tests := []struct {
+		name  string
+		codec Codec
+		v     any
+		want  string
+	}{
+		{"query", Query, p, "email=foo@bar.com&uid=10&role=admin&Admin=true&Count=3"},
+		{"pointer", Query, &p, "email=foo@bar.com&uid=10&role=admin&Admin=true&Count=3"},
+		{"raw", semicolon, profile{Email: "a;role=admin"}, "email=a;role=admin;uid=0;role=user;Admin=false;Count=0"},
+		{"percent", Cookie, profile{Email: "a b;c=d%\xff"}, "email=a%20b%3Bc%3Dd%25%FF;uid=0;role=user;Admin=false;Count=0"},
+		{"percent plain", Cookie, profile{Email: "foo@bar.com"}, "email=foo@bar.com;uid=0;role=user;Admin=false;Count=0"},
+	}
+	for _, tt := range tests {
+		got, err := tt.codec.Marshal(tt.v)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		v     any
+	}{
+		{"reject sep", Query, profile{Email: "a&role=admin"}},
+		{"reject assign", Query, profile{Email: "role=admin"}},
+		{"text marshaler", Query, profile{Role: 7}},
+		{"nil pointer", Query, (*profile)(nil)},
+		{"non-struct", Query, "email=foo"},
+		{"unsupported field", Query, struct{ F float64 }{}},
+	}
+	for _, tt := range tests {
+		if got, err := tt.codec.Marshal(tt.v); err == nil {
+			t.Errorf("%s: got %q, want an error", tt.name, got)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		in    string
+		want  profile
+	}{
+		{"empty", Query, "", profile{}},
+		{"query", Query, "email=foo@bar.com&uid=10&role=admin&Admin=true&Count=3", profile{Email: "foo@bar.com", UID: 10, Role: 1, Admin: true, Count: 3}},
+		{"raw splits at the first assign", semicolon, "email=a=b;uid=1", profile{Email: "a=b", UID: 1}},
+		{"percent", Cookie, "email=a%20b%3Bc%3Dd%25%ff", profile{Email: "a b;c=d%\xff"}},
+		{"last wins", Query, "role=user&role=admin", profile{Role: 1}},
+		{"first wins", Codec{Sep: "&", Assign: "=", Dups: FirstWins}, "role=user&role=admin", profile{}},
+		{"reject duplicates allows distinct keys", Codec{Sep: "&", Assign: "=", Dups: RejectDuplicates}, "email=a&uid=2", profile{Email: "a", UID: 2}},
+	}
+	for _, tt := range tests {
+		var got profile
+		if err := tt.codec.Unmarshal(tt.in, &got); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		in    string
+		want  string
+	}{
+		{"no assign", Query, "email", `has no "="`},
+		{"reject extra assign", Query, "email=a=b", "more than one"},
+		{"percent extra assign", Cookie, "email=a=b", "more than one"},
+		{"unknown key", Query, "email=a&admin=true", `unknown key "admin"`},
+		{"skipped field", Query, "-=s", `unknown key "-"`},
+		{"tagged field by name", Query, "Email=a", `unknown key "Email"`},
+		{"unexported field", Query, "hidden=h", `unknown key "hidden"`},
+		{"duplicate", Codec{Sep: "&", Assign: "=", Dups: RejectDuplicates}, "uid=1&uid=2", `duplicate key "uid"`},
+		{"truncated escape", Cookie, "email=a%2", "truncated escape"},
+		{"truncated escape at end", Cookie, "email=a%", "truncated escape"},
+		{"bad escape", Cookie, "email=%zz", `bad escape "%zz"`},
+		{"bad int", Query, "uid=ten", "uid"},
+		{"uint overflow", Query, "Count=256", "Count"},
+		{"bad bool", Query, "Admin=yes", "Admin"},
+		{"text unmarshaler", Query, "role=root", "bad level"},
+	}
+	for _, tt := range tests {
+		var p profile
+		err := tt.codec.Unmarshal(tt.in, &p)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+
+	var p profile
+	for _, v := range []any{nil, p, &p.Email} {
+		if err := Unmarshal("email=a", v); err == nil {
+			t.Errorf("Unmarshal into %T succeeded", v)
+		}
+	}
+}
+
+func TestPercentRoundTrip(t *testing.T) {
+	var all strings.Builder
+	for c := range 256 {
+		all.WriteByte(byte(c))
+	}
+	for _, s := range []string{"", "plain", "100%", "%41", ";admin=true;", " \t\n", all.String()} {
+		in := profile{Email: s, Secret: "not encoded"}
+		enc, err := Cookie.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out profile
+		if err := Cookie.Unmarshal(enc, &out); err != nil {
+			t.Fatalf("%q: %v", enc, err)
+		}
+		if out.Email != s || out.Secret != "" {
+			t.Errorf("%q came back as %+v", s, out)
+		}
+	}
+}
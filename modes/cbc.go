@@ -0,0 +1,66 @@
+package modes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"slices"
+)
+
+type CBC struct {
+	prevBlock []byte
+	block     cipher.Block
+	blen      int
+}
+
+func NewAESCBC(key, iv []byte) (CBC, error) {
+	aes, err := aes.NewCipher(key)
+	if err != nil {
+		return CBC{}, err
+	}
+
+	blen := aes.BlockSize()
+	if len(iv) != blen {
+		return CBC{}, fmt.Errorf("iv length %d does not match block length %d", len(iv), blen)
+	}
+
+	prevBlock := make([]byte, blen)
+	copy(prevBlock, iv)
+	return CBC{
+		prevBlock: prevBlock,
+		block:     aes,
+		blen:      blen,
+	}, nil
+}
+
+func (cbc *CBC) BlockSize() int {
+	return cbc.blen
+}
+
+func (cbc *CBC) handleBlock(dst []byte, block []byte, enc bool) {
+	src := make([]byte, cbc.blen)
+	copy(src, block)
+
+	res := make([]byte, cbc.blen)
+	if enc {
+		XORBytes(src, cbc.prevBlock)
+		cbc.block.Encrypt(res, src)
+		cbc.prevBlock = res
+	} else {
+		cbc.block.Decrypt(res, src)
+		XORBytes(res, cbc.prevBlock)
+		copy(cbc.prevBlock, src)
+	}
+
+	copy(dst, res)
+}
+
+func (cbc *CBC) HandleBytes(dst []byte, src []byte, enc bool) {
+	blocks := slices.Chunk(src, cbc.blen)
+
+	var blockIdx int
+	for block := range blocks {
+		cbc.handleBlock(dst[blockIdx*cbc.blen:(blockIdx+1)*cbc.blen], block, enc)
+		blockIdx++
+	}
+}
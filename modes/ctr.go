@@ -0,0 +1,65 @@
+package modes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// CTR is counter mode with the same keystream block layout as the Rust
+// chal18 solution: the nonce big endian in the first half of the block and
+// the block counter big endian in the second.
+type CTR struct {
+	block     cipher.Block
+	nonce     uint64
+	ctr       uint64
+	keystream []byte
+	used      int
+}
+
+func NewAESCTR(key []byte, nonce uint64) (CTR, error) {
+	aes, err := aes.NewCipher(key)
+	if err != nil {
+		return CTR{}, err
+	}
+
+	blen := aes.BlockSize()
+	return CTR{
+		block:     aes,
+		nonce:     nonce,
+		keystream: make([]byte, blen),
+		used:      blen,
+	}, nil
+}
+
+func (ctr *CTR) BlockSize() int {
+	return len(ctr.keystream)
+}
+
+func (ctr *CTR) nextBlock() {
+	blen := len(ctr.keystream)
+	in := make([]byte, blen)
+	binary.BigEndian.PutUint64(in, ctr.nonce)
+	binary.BigEndian.PutUint64(in[blen/2:], ctr.ctr)
+	ctr.block.Encrypt(ctr.keystream, in)
+	ctr.ctr++
+	ctr.used = 0
+}
+
+// XORKeyStream XORs src with the next len(src) bytes of keystream. Unlike the
+// block modes, src may be any length.
+func (ctr *CTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if ctr.used == len(ctr.keystream) {
+			ctr.nextBlock()
+		}
+		dst[i] = src[i] ^ ctr.keystream[ctr.used]
+		ctr.used++
+	}
+}
+
+// HandleBytes is XORKeyStream; encryption and decryption are the same
+// operation in counter mode.
+func (ctr *CTR) HandleBytes(dst []byte, src []byte, enc bool) {
+	ctr.XORKeyStream(dst, src)
+}
@@ -0,0 +1,46 @@
+package modes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"slices"
+)
+
+type ECB struct {
+	block cipher.Block
+	blen  int
+}
+
+func NewAESECB(key []byte) (ECB, error) {
+	aes, err := aes.NewCipher(key)
+	if err != nil {
+		return ECB{}, err
+	}
+
+	return ECB{
+		block: aes,
+		blen:  aes.BlockSize(),
+	}, nil
+}
+
+func (ecb *ECB) BlockSize() int {
+	return ecb.blen
+}
+
+func (ecb *ECB) handleBlock(dst []byte, block []byte, enc bool) {
+	if enc {
+		ecb.block.Encrypt(dst, block)
+	} else {
+		ecb.block.Decrypt(dst, block)
+	}
+}
+
+func (ecb *ECB) HandleBytes(dst []byte, src []byte, enc bool) {
+	blocks := slices.Chunk(src, ecb.blen)
+
+	var blockIdx int
+	for block := range blocks {
+		ecb.handleBlock(dst[blockIdx*ecb.blen:(blockIdx+1)*ecb.blen], block, enc)
+		blockIdx++
+	}
+}
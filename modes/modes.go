@@ -0,0 +1,59 @@
+// Package modes holds the AES block cipher modes and padding shared by the
+// challenge solutions.
+package modes
+
+import (
+	"errors"
+	"slices"
+)
+
+// Mode encrypts or decrypts whole messages. The block modes expect src to be
+// a multiple of the block length; padding is up to the caller.
+type Mode interface {
+	HandleBytes(dst []byte, src []byte, enc bool)
+}
+
+func PKCS7Pad(x []byte, blen int) []byte {
+	missing := (-int(len(x))) % int(blen)
+	if missing < 0 {
+		missing += int(blen)
+	} else if missing == 0 {
+		missing = blen
+	}
+
+	pad := make([]byte, missing)
+	for i := range len(pad) {
+		pad[i] = byte(missing)
+	}
+
+	return slices.Concat(x, pad)
+}
+
+func PKCS7Strip(x []byte, blen int) ([]byte, error) {
+	if len(x)%blen != 0 {
+		return nil, errors.New("not a multiple of the block length")
+	}
+
+	if len(x) == 0 {
+		return nil, errors.New("pkcs7 padded string cannot have length zero")
+	}
+
+	lastByte := int(x[len(x)-1])
+	if lastByte < 1 || lastByte > blen {
+		return nil, errors.New("pkcs7 last byte must satisfy 1 <= last byte <= blen")
+	}
+
+	for i := len(x) - lastByte; i < len(x); i++ {
+		if int(x[i]) != lastByte {
+			return nil, errors.New("mismatched padding bytes")
+		}
+	}
+
+	return x[:len(x)-lastByte], nil
+}
+
+func XORBytes(dst, src []byte) {
+	for i := 0; i < len(dst); i++ {
+		dst[i] = dst[i] ^ src[i]
+	}
+}
@@ -0,0 +1,162 @@
+package oracle
+
+import (
+	"bytes"
+	"crypto/aes"
+	"io"
+
+	"github.com/fharding1/cryptopals/kv"
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/random"
+)
+
+var fixedKey = []byte{45, 135, 181, 151, 22, 24, 120, 192, 131, 254, 4, 183, 111, 38, 52, 59}
+
+// userdata is the string the bitflipping oracle encrypts, in kv.Cookie form.
+type userdata struct {
+	Comment1 string `kv:"comment1"`
+	Userdata string `kv:"userdata"`
+	Comment2 string `kv:"comment2"`
+}
+
+// Userdata returns the bitflipping oracle. Encryption percent-encodes the
+// input, embeds it in a cookie style string and encrypts it with AES-CBC under a
+// random IV, which is prepended to the ciphertext. Decryption returns the
+// plaintext, padding included.
+func Userdata(rand io.Reader) func(src []byte, enc bool) []byte {
+	return UserdataWith(rand, Secrets{})
+}
+
+// UserdataWith is Userdata with its key taken from s if set.
+func UserdataWith(rand io.Reader, s Secrets) func(src []byte, enc bool) []byte {
+	key := fixedKey
+	if s.Key != nil {
+		key = s.Key
+	}
+
+	return func(src []byte, enc bool) []byte {
+		var dst []byte
+		if enc {
+			iv := make([]byte, 16)
+			random.Read(rand, iv)
+
+			block, err := modes.NewAESCBC(key, iv)
+			if err != nil {
+				panic(err)
+			}
+
+			ptxt := modes.PKCS7Pad(UserdataString(src), aes.BlockSize)
+			dst = make([]byte, len(ptxt)+16)
+
+			copy(dst, iv)
+
+			block.HandleBytes(dst[16:], ptxt, enc)
+		} else {
+			if len(src) < 16 || len(src)%16 != 0 {
+				return nil
+			}
+			dst = make([]byte, len(src)-16)
+
+			iv := src[:16]
+			block, err := modes.NewAESCBC(key, iv)
+			if err != nil {
+				panic(err)
+			}
+
+			block.HandleBytes(dst, src[16:], enc)
+		}
+
+		return dst
+	}
+}
+
+// UserdataString returns the cookie style string the bitflipping oracle
+// encrypts for src.
+func UserdataString(src []byte) []byte {
+	encoded, err := kv.Cookie.Marshal(userdata{
+		Comment1: "cooking MCs",
+		Userdata: string(src),
+		Comment2: " like a pound of bacon",
+	})
+	if err != nil {
+		panic(err)
+	}
+	return []byte(encoded)
+}
+
+// IsAdmin reports whether a decrypted userdata string contains an
+// admin=true field.
+func IsAdmin(ptxt []byte) bool {
+	for _, field := range bytes.Split(ptxt, []byte(";")) {
+		if string(field) == "admin=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// PaddingOracleStrings are the plaintexts the padding oracle challenge picks
+// from, base64 encoded.
+var PaddingOracleStrings = []string{
+	"MDAwMDAwTm93IHRoYXQgdGhlIHBhcnR5IGlzIGp1bXBpbmc=",
+	"MDAwMDAxV2l0aCB0aGUgYmFzcyBraWNrZWQgaW4gYW5kIHRoZSBWZWdhJ3MgYXJlIHB1bXBpbic=",
+	"MDAwMDAyUXVpY2sgdG8gdGhlIHBvaW50LCB0byB0aGUgcG9pbnQsIG5vIGZha2luZw==",
+	"MDAwMDAzQ29va2luZyBNQydzIGxpa2UgYSBwb3VuZCBvZiBiYWNvbg==",
+	"MDAwMDA0QnVybmluZyAnZW0sIGlmIHlvdSBhaW4ndCBxdWljayBhbmQgbmltYmxl",
+	"MDAwMDA1SSBnbyBjcmF6eSB3aGVuIEkgaGVhciBhIGN5bWJhbA==",
+	"MDAwMDA2QW5kIGEgaGlnaCBoYXQgd2l0aCBhIHNvdXBlZCB1cCB0ZW1wbw==",
+	"MDAwMDA3SSdtIG9uIGEgcm9sbCwgaXQncyB0aW1lIHRvIGdvIHNvbG8=",
+	"MDAwMDA4b2xsaW4nIGluIG15IGZpdmUgcG9pbnQgb2g=",
+	"MDAwMDA5aXRoIG15IHJhZy10b3AgZG93biBzbyBteSBoYWlyIGNhbiBibG93",
+}
+
+// CBCPadding returns the padding oracle: an AES-CBC encryption function,
+// which prepends a random IV, and a decryption function that only reports
+// whether the plaintext's padding is valid.
+func CBCPadding(rand io.Reader) (enc func(src []byte) []byte, dec func(src []byte) bool) {
+	return CBCPaddingWith(rand, Secrets{})
+}
+
+// CBCPaddingWith is CBCPadding with its key taken from s if set.
+func CBCPaddingWith(rand io.Reader, s Secrets) (enc func(src []byte) []byte, dec func(src []byte) bool) {
+	key := fixedKey
+	if s.Key != nil {
+		key = s.Key
+	}
+
+	return func(src []byte) []byte {
+			var dst []byte
+			iv := make([]byte, 16)
+			random.Read(rand, iv)
+
+			block, err := modes.NewAESCBC(key, iv)
+			if err != nil {
+				panic(err)
+			}
+
+			ptxt := modes.PKCS7Pad(src, aes.BlockSize)
+			dst = make([]byte, len(ptxt)+16)
+
+			copy(dst, iv)
+
+			block.HandleBytes(dst[16:], ptxt, true)
+
+			return dst
+		}, func(src []byte) bool {
+			if len(src) < 32 || len(src)%16 != 0 {
+				return false
+			}
+			dst := make([]byte, len(src)-16)
+
+			iv := src[:16]
+			block, err := modes.NewAESCBC(key, iv)
+			if err != nil {
+				panic(err)
+			}
+
+			block.HandleBytes(dst, src[16:], false)
+
+			_, err = modes.PKCS7Strip(dst, 16)
+			return err == nil
+		}
+}
@@ -0,0 +1,102 @@
+package oracle
+
+import (
+	"sort"
+
+	"github.com/fharding1/cryptopals/kv"
+)
+
+// ProfilePolicies are the decoding policies the differential harness
+// compares, by name. All but "raw" read kv.Query's format.
+var ProfilePolicies = map[string]ProfilePolicy{
+	"last-wins":         DefaultProfilePolicy,
+	"first-wins":        {Codec: kv.Codec{Sep: "&", Assign: "=", Escape: kv.Reject, Dups: kv.FirstWins}},
+	"reject-duplicates": {Codec: kv.Codec{Sep: "&", Assign: "=", Escape: kv.Reject, Dups: kv.RejectDuplicates}},
+	"strict-email":      {Codec: kv.Query, Email: StrictEmail},
+	"raw":               {Codec: kv.Codec{Sep: "&", Assign: "="}},
+}
+
+// InjectionEmails are emails that try to smuggle a role=admin pair, or
+// just an extra "=" or "&", into an encoded profile.
+var InjectionEmails = []string{
+	"foo@bar.com&role=admin",
+	"foo@bar.com&uid=10&role=admin",
+	"foo@bar.com&role=admin&uid=0",
+	"foo@bar.com&role=admin&role=admin",
+	`"foo&role=admin"@bar.com`,
+	`"role=admin"@bar.com`,
+	"foo@bar.com (&role=admin)",
+	"Admin <foo@bar.com>",
+	"foo+admin@bar.com",
+}
+
+// Outcome is what one policy decoded an input to.
+type Outcome struct {
+	Policy  string
+	Profile Profile
+	Err     error
+}
+
+// Differential decodes str under each of policies, in name order.
+func Differential(str string, policies map[string]ProfilePolicy) []Outcome {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	outcomes := make([]Outcome, len(names))
+	for i, name := range names {
+		outcomes[i].Policy = name
+		outcomes[i].Err = policies[name].Decode(str, &outcomes[i].Profile)
+	}
+	return outcomes
+}
+
+// Agree reports whether every outcome failed, or every one succeeded with the
+// same profile.
+func Agree(outcomes []Outcome) bool {
+	for _, o := range outcomes {
+		first := outcomes[0]
+		if (o.Err == nil) != (first.Err == nil) || o.Err == nil && o.Profile != first.Profile {
+			return false
+		}
+	}
+	return true
+}
+
+// Finding is an encoded profile the decoding policies disagree on.
+type Finding struct {
+	Email    string
+	Encoded  string
+	Outcomes []Outcome
+}
+
+// Admin reports whether any policy decoded the profile as an admin.
+func (f Finding) Admin() bool {
+	for _, o := range f.Outcomes {
+		if o.Err == nil && o.Profile.Role == Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe encodes a user profile for each email under enc, as the profile
+// oracle's profile_for would, and decodes the result under every policy in
+// decs. It returns the encodings the policies disagree on. Emails enc
+// refuses to encode are skipped.
+func Probe(enc ProfilePolicy, decs map[string]ProfilePolicy, emails []string) []Finding {
+	var findings []Finding
+	for _, email := range emails {
+		encoded, err := enc.Encode(Profile{Email: email, UID: 10, Role: User})
+		if err != nil {
+			continue
+		}
+		outcomes := Differential(encoded, decs)
+		if !Agree(outcomes) {
+			findings = append(findings, Finding{Email: email, Encoded: encoded, Outcomes: outcomes})
+		}
+	}
+	return findings
+}
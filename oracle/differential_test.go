@@ -0,0 +1,82 @@
+package oracle
+
+import "testing"
+
+func TestDifferential(t *testing.T) {
+	// want maps each policy to the role it decodes, or "error".
+	tests := []struct {
+		str  string
+		want map[string]string
+	}{
+		{
+			"email=foo@bar.com&uid=10&role=user",
+			map[string]string{"first-wins": "user", "last-wins": "user", "raw": "user", "reject-duplicates": "user", "strict-email": "user"},
+		},
+		{
+			"email=foo@bar.com&uid=10&role=user&role=admin",
+			map[string]string{"first-wins": "user", "last-wins": "admin", "raw": "admin", "reject-duplicates": "error", "strict-email": "admin"},
+		},
+		{
+			"email=foo@bar.com&role=admin&uid=10&role=user",
+			map[string]string{"first-wins": "admin", "last-wins": "user", "raw": "user", "reject-duplicates": "error", "strict-email": "user"},
+		},
+		{
+			"email=Admin <foo@bar.com>&uid=10&role=user",
+			map[string]string{"first-wins": "user", "last-wins": "user", "raw": "user", "reject-duplicates": "user", "strict-email": "error"},
+		},
+		{
+			"email=foo@bar.com&uid=10&role=root",
+			map[string]string{"first-wins": "error", "last-wins": "error", "raw": "error", "reject-duplicates": "error", "strict-email": "error"},
+		},
+	}
+
+	for _, tt := range tests {
+		outcomes := Differential(tt.str, ProfilePolicies)
+		if len(outcomes) != len(tt.want) {
+			t.Fatalf("%q: %d outcomes, want %d", tt.str, len(outcomes), len(tt.want))
+		}
+		// Apart from the role, every case decodes the same profile.
+		agree := true
+		for _, o := range outcomes {
+			got := o.Profile.Role.String()
+			if o.Err != nil {
+				got = "error"
+			}
+			if got != tt.want[o.Policy] {
+				t.Errorf("%q under %s: got %s (%v), want %s", tt.str, o.Policy, got, o.Err, tt.want[o.Policy])
+			}
+			agree = agree && tt.want[o.Policy] == tt.want[outcomes[0].Policy]
+		}
+		if Agree(outcomes) != agree {
+			t.Errorf("%q: Agree = %v, want %v", tt.str, !agree, agree)
+		}
+	}
+}
+
+func TestAgreeEmpty(t *testing.T) {
+	if !Agree(nil) {
+		t.Error("Agree(nil) = false, want true")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	// Encoding without escaping lets the injections through, and some
+	// policy then reads them as an admin.
+	var admins int
+	for _, f := range Probe(ProfilePolicies["raw"], ProfilePolicies, InjectionEmails) {
+		if f.Admin() {
+			admins++
+		}
+	}
+	if admins == 0 {
+		t.Error("no injection through the raw encoder made an admin")
+	}
+
+	// The oracle's own encoder rejects "&" and "=" in values, so whatever the
+	// policies disagree on, none of them reads an admin.
+	for _, f := range Probe(DefaultProfilePolicy, ProfilePolicies, InjectionEmails) {
+		if f.Admin() {
+			t.Errorf("%q encoded to %q, which decodes as an admin", f.Email, f.Encoded)
+		}
+	}
+}
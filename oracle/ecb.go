@@ -0,0 +1,53 @@
+package oracle
+
+import (
+	"crypto/aes"
+	"io"
+	"slices"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/modes"
+)
+
+var unknownString = "Um9sbGluJyBpbiBteSA1LjAKV2l0aCBteSByYWctdG9wIGRvd24gc28gbXkgaGFpciBjYW4gYmxvdwpUaGUgZ2lybGllcyBvbiBzdGFuZGJ5IHdhdmluZyBqdXN0IHRvIHNheSBoaQpEaWQgeW91IHN0b3A/IE5vLCBJIGp1c3QgZHJvdmUgYnkK"
+
+// DefaultSuffix returns the secret ByteAtATime appends unless Secrets.Suffix
+// is set.
+func DefaultSuffix() []byte {
+	suffix, err := codec.StdEncoding.DecodeString(unknownString)
+	if err != nil {
+		panic(err)
+	}
+	return suffix
+}
+
+// ByteAtATime returns an oracle that appends a secret string to its input
+// and encrypts the result with AES-ECB under a key fixed for the life of the
+// oracle.
+func ByteAtATime(rand io.Reader) func(src []byte) []byte {
+	return ByteAtATimeWith(rand, Secrets{})
+}
+
+// ByteAtATimeWith is ByteAtATime with its key and appended secret taken from
+// s where set.
+func ByteAtATimeWith(rand io.Reader, s Secrets) func(src []byte) []byte {
+	key := s.key(rand)
+
+	suffix := s.Suffix
+	if suffix == nil {
+		suffix = DefaultSuffix()
+	}
+
+	return func(src []byte) []byte {
+		ptxt := modes.PKCS7Pad(slices.Concat(src, suffix), aes.BlockSize)
+		ctxt := make([]byte, len(ptxt))
+
+		block, err := modes.NewAESECB(key)
+		if err != nil {
+			panic(err)
+		}
+		block.HandleBytes(ctxt, ptxt, true)
+
+		return ctxt
+	}
+}
@@ -0,0 +1,217 @@
+package oracle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/random"
+)
+
+// The HTTP API serves every challenge oracle as a JSON web service. Binary
+// values are hex strings. Requests with a body send {"data": "<hex>"}, and
+// failures answer with a 4xx status and {"error": "<message>"}.
+//
+//	POST /detect/encrypt                 {"data"} -> {"data"}
+//	POST /byte-at-a-time/encrypt         {"data"} -> {"data"}
+//	GET  /profile/profile_for?email=...            -> {"data"}
+//	POST /profile/decrypt                {"data"} -> {"email", "uid", "role"}
+//	POST /userdata/encrypt               {"data"} -> {"data"}
+//	POST /userdata/decrypt               {"data"} -> {"data", "admin"}
+//	GET  /padding/challenge                        -> {"data"}
+//	POST /padding/encrypt                {"data"} -> {"data"}
+//	POST /padding/validate-padding       {"data"} -> {"valid"}
+//
+// validate-padding answers 200 for valid padding and 400 otherwise, leaking
+// the same bit a careless real service would.
+
+type httpData struct {
+	Data string `json:"data"`
+}
+
+type httpError struct {
+	Error string `json:"error"`
+	// retryAfter is sent as the Retry-After header, rounded up to seconds.
+	retryAfter time.Duration
+}
+
+type httpProfile struct {
+	Email string `json:"email"`
+	UID   int    `json:"uid"`
+	Role  string `json:"role"`
+}
+
+type httpUserdata struct {
+	Data  string `json:"data"`
+	Admin bool   `json:"admin"`
+}
+
+type httpValid struct {
+	Valid bool `json:"valid"`
+}
+
+// NewHTTPHandler serves the challenge oracles, built from rand and s, over
+// HTTP, passing every query through mws. Refused queries are answered 429
+// for ErrRateLimited and ErrOverBudget, 503 for ErrUnavailable and 403 for
+// ErrLockedOut, with a Retry-After header for a RetryAfterError. Middleware
+// is given each request's context. The oracles are not safe for concurrent
+// use, so they answer one query at a time; middleware runs outside that
+// lock, so simulated latency overlaps.
+func NewHTTPHandler(rand io.Reader, s Secrets, mws ...Middleware) http.Handler {
+	var mu sync.Mutex
+	detect, _ := ModeDetection(rand)
+	byteAtATime := ByteAtATimeWith(rand, s)
+	profile := ProfileECBWith(rand, s)
+	userdata := UserdataWith(rand, s)
+	paddingEnc, paddingDec := CBCPaddingWith(rand, s)
+
+	// query passes one query through mws to o.
+	query := func(r *http.Request, op Op, src []byte, o func() Result) Result {
+		return chain(r.Context(), op, src, mws, func() Result {
+			mu.Lock()
+			defer mu.Unlock()
+			return o()
+		})
+	}
+
+	mux := http.NewServeMux()
+	handle := func(pattern string, h func(r *http.Request) (int, any)) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			status, resp := h(r)
+
+			if e, ok := resp.(httpError); ok && e.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.retryAfter.Seconds()))))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(resp)
+		})
+	}
+	bytesEndpoint := func(op Op, o func([]byte) []byte) func(r *http.Request) (int, any) {
+		return func(r *http.Request) (int, any) {
+			src, err := readHTTPData(r)
+			if err != nil {
+				return httpFail(err)
+			}
+			res := query(r, op, src, func() Result { return Result{Output: o(src)} })
+			if res.Err != nil {
+				return httpRefuse(res.Err)
+			}
+			return http.StatusOK, httpData{codec.HexEncodeToString(res.Output)}
+		}
+	}
+
+	handle("POST /detect/encrypt", bytesEndpoint(OpEncrypt, detect))
+	handle("POST /byte-at-a-time/encrypt", bytesEndpoint(OpEncrypt, byteAtATime))
+
+	handle("GET /profile/profile_for", func(r *http.Request) (int, any) {
+		encoded, err := Profile{Email: r.URL.Query().Get("email"), UID: 10, Role: User}.Encode()
+		if err != nil {
+			return httpFail(err)
+		}
+		res := query(r, OpEncrypt, []byte(encoded), func() Result { return Result{Output: profile([]byte(encoded), true)} })
+		if res.Err != nil {
+			return httpRefuse(res.Err)
+		}
+		return http.StatusOK, httpData{codec.HexEncodeToString(res.Output)}
+	})
+	handle("POST /profile/decrypt", func(r *http.Request) (int, any) {
+		src, err := readHTTPData(r)
+		if err != nil {
+			return httpFail(err)
+		}
+		res := query(r, OpDecrypt, src, func() Result { return Result{Output: profile(src, false)} })
+		if res.Err != nil {
+			return httpRefuse(res.Err)
+		}
+		if res.Output == nil {
+			return httpFail(errors.New("invalid padding"))
+		}
+		var p Profile
+		if err := p.Decode(string(res.Output)); err != nil {
+			return httpFail(err)
+		}
+		return http.StatusOK, httpProfile{Email: p.Email, UID: p.UID, Role: p.Role.String()}
+	})
+
+	handle("POST /userdata/encrypt", bytesEndpoint(OpEncrypt, func(src []byte) []byte { return userdata(src, true) }))
+	handle("POST /userdata/decrypt", func(r *http.Request) (int, any) {
+		src, err := readHTTPData(r)
+		if err != nil {
+			return httpFail(err)
+		}
+		res := query(r, OpDecrypt, src, func() Result { return Result{Output: userdata(src, false)} })
+		if res.Err != nil {
+			return httpRefuse(res.Err)
+		}
+		if res.Output == nil {
+			return httpFail(errors.New("ciphertext is not a whole number of blocks"))
+		}
+		return http.StatusOK, httpUserdata{Data: codec.HexEncodeToString(res.Output), Admin: IsAdmin(res.Output)}
+	})
+
+	handle("GET /padding/challenge", func(r *http.Request) (int, any) {
+		mu.Lock()
+		defer mu.Unlock()
+		ptxt, err := codec.StdEncoding.DecodeString(PaddingOracleStrings[random.Intn(rand, len(PaddingOracleStrings))])
+		if err != nil {
+			panic(err)
+		}
+		return http.StatusOK, httpData{codec.HexEncodeToString(paddingEnc(ptxt))}
+	})
+	handle("POST /padding/encrypt", bytesEndpoint(OpEncrypt, paddingEnc))
+	handle("POST /padding/validate-padding", func(r *http.Request) (int, any) {
+		src, err := readHTTPData(r)
+		if err != nil {
+			return httpFail(err)
+		}
+		res := query(r, OpPadding, src, func() Result { return Result{Valid: paddingDec(src)} })
+		if res.Err != nil {
+			return httpRefuse(res.Err)
+		}
+		if !res.Valid {
+			return http.StatusBadRequest, httpValid{false}
+		}
+		return http.StatusOK, httpValid{true}
+	})
+
+	return mux
+}
+
+// readHTTPData decodes a {"data": "<hex>"} request body.
+func readHTTPData(r *http.Request) ([]byte, error) {
+	var req httpData
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20)).Decode(&req); err != nil {
+		return nil, fmt.Errorf("reading request: %w", err)
+	}
+	return codec.HexDecodeString(req.Data)
+}
+
+func httpFail(err error) (int, any) {
+	return http.StatusBadRequest, httpError{Error: err.Error()}
+}
+
+// httpRefuse answers a query middleware refused, telling the client when to
+// come back if the refusal says.
+func httpRefuse(err error) (int, any) {
+	status := http.StatusServiceUnavailable
+	switch {
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrOverBudget):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, ErrLockedOut):
+		status = http.StatusForbidden
+	}
+	resp := httpError{Error: err.Error()}
+	var ra *RetryAfterError
+	if errors.As(err, &ra) {
+		resp.retryAfter = ra.After
+	}
+	return status, resp
+}
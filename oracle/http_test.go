@@ -0,0 +1,35 @@
+package oracle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHandlerRetryAfter(t *testing.T) {
+	limit := &RateLimit{Rate: 0.2, Burst: 1}
+	srv := httptest.NewServer(NewHTTPHandler(nil, Secrets{}, limit.Limit))
+	defer srv.Close()
+
+	post := func() *http.Response {
+		resp, err := http.Post(srv.URL+"/byte-at-a-time/encrypt", "application/json", strings.NewReader(`{"data":"41"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := post(); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first query: status %d", resp.StatusCode)
+	}
+	resp := post()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second query: status %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	// The next token is five seconds away at 0.2 a second.
+	if got := resp.Header.Get("Retry-After"); got != "5" {
+		t.Errorf("Retry-After = %q, want 5", got)
+	}
+}
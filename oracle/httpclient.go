@@ -0,0 +1,330 @@
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+// Location is where an HTTP oracle request carries its input.
+type Location int
+
+const (
+	InBody Location = iota
+	InQuery
+	InCookie
+)
+
+func (l Location) String() string {
+	switch l {
+	case InBody:
+		return "body"
+	case InQuery:
+		return "query"
+	case InCookie:
+		return "cookie"
+	}
+	return fmt.Sprintf("Location(%d)", int(l))
+}
+
+// Request is the template for the HTTP request sent for every query.
+type Request struct {
+	// Method defaults to POST for inputs in the body and GET otherwise.
+	Method string
+	URL    string
+	In     Location
+	// Name is the query parameter or cookie that holds the input.
+	Name string
+	// Body is the body for inputs in the body, with every "{data}" replaced
+	// by the encoded input. Empty sends the encoded input alone.
+	Body   string
+	Header http.Header
+	// Encoding is how inputs are encoded and outputs decoded: codec.Hex,
+	// codec.Base64 or codec.Base64URL. codec.Raw sends bytes unchanged.
+	Encoding codec.Format
+}
+
+// Response says how to read the answer to a query.
+type Response struct {
+	// Field is the top level JSON field holding a bytes oracle's output.
+	// Empty reads the whole body.
+	Field string
+	// Valid decides whether a validity oracle accepted its input. Nil
+	// accepts any 2xx status.
+	Valid func(status int, body []byte) bool
+}
+
+// ValidStatus accepts exactly the given status codes.
+func ValidStatus(codes ...int) func(status int, body []byte) bool {
+	return func(status int, _ []byte) bool {
+		for _, code := range codes {
+			if status == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// InvalidBody rejects any response whose body contains s.
+func InvalidBody(s string) func(status int, body []byte) bool {
+	return func(_ int, body []byte) bool {
+		return !strings.Contains(string(body), s)
+	}
+}
+
+// HTTPOracle is an oracle behind an HTTP endpoint, queried by filling in a
+// Request template. It is an encryption, decryption or padding oracle
+// depending on the endpoint. Its plain methods answer a failed query with
+// nil or false; the package's Encrypt, Decrypt and ValidPadding functions
+// return the error. It is safe for concurrent use.
+type HTTPOracle struct {
+	Request  Request
+	Response Response
+	// Client is shared by every query so connections are reused.
+	Client *http.Client
+	// Retries is how many more times a query is tried after a network
+	// error or a 429, 502, 503 or 504 status, waiting Backoff and then twice as long
+	// each time, or longer if the response has a Retry-After.
+	Retries int
+	Backoff time.Duration
+}
+
+// NewHTTPOracle returns an HTTPOracle with a client that keeps enough idle
+// connections for a fast attack, three retries and a 100ms initial backoff.
+func NewHTTPOracle(req Request, resp Response) *HTTPOracle {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 16
+
+	return &HTTPOracle{
+		Request:  req,
+		Response: resp,
+		Client:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		Retries:  3,
+		Backoff:  100 * time.Millisecond,
+	}
+}
+
+func encodeAs(f codec.Format, b []byte) (string, error) {
+	switch f {
+	case codec.Raw:
+		return string(b), nil
+	case codec.Hex:
+		return codec.HexEncodeToString(b), nil
+	case codec.Base64:
+		return codec.StdEncoding.EncodeToString(b), nil
+	case codec.Base64URL:
+		return codec.URLEncoding.EncodeToString(b), nil
+	}
+	return "", fmt.Errorf("cannot encode requests as %v", f)
+}
+
+func decodeAs(f codec.Format, s string) ([]byte, error) {
+	switch f {
+	case codec.Raw:
+		return []byte(s), nil
+	case codec.Hex:
+		return codec.HexDecodeString(s)
+	case codec.Base64:
+		return codec.StdEncoding.DecodeString(s)
+	case codec.Base64URL:
+		return codec.URLEncoding.DecodeString(s)
+	}
+	return nil, fmt.Errorf("cannot decode responses as %v", f)
+}
+
+func (o *HTTPOracle) newRequest(ctx context.Context, src []byte) (*http.Request, error) {
+	t := o.Request
+	data, err := encodeAs(t.Encoding, src)
+	if err != nil {
+		return nil, err
+	}
+
+	method := t.Method
+	if method == "" {
+		method = http.MethodGet
+		if t.In == InBody {
+			method = http.MethodPost
+		}
+	}
+
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	switch t.In {
+	case InBody:
+		if t.Body == "" {
+			body = strings.NewReader(data)
+		} else {
+			body = strings.NewReader(strings.ReplaceAll(t.Body, "{data}", data))
+		}
+	case InQuery:
+		q := u.Query()
+		q.Set(t.Name, data)
+		u.RawQuery = q.Encode()
+	case InCookie:
+	default:
+		return nil, fmt.Errorf("unknown input location %v", t.In)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range t.Header {
+		req.Header[k] = vs
+	}
+	if t.In == InCookie {
+		req.AddCookie(&http.Cookie{Name: t.Name, Value: data})
+	}
+	return req, nil
+}
+
+// Query sends src and returns the response status and body, retrying
+// transient failures. A status still transient after the retries is
+// returned with an error wrapping ErrRateLimited or ErrUnavailable, as
+// refusal maps it. It gives up, between retries too, once ctx is done.
+func (o *HTTPOracle) Query(ctx context.Context, src []byte) (int, []byte, error) {
+	backoff := o.Backoff
+	for attempt := 0; ; attempt++ {
+		req, err := o.newRequest(ctx, src)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		status, body, retryAfter, err := o.do(req)
+		retry := err != nil || transient(status)
+		if !retry || attempt >= o.Retries {
+			if err == nil && retry {
+				err = fmt.Errorf("%s %s: status %d after %d attempts: %w", req.Method, req.URL, status, attempt+1, refusal(status, retryAfter))
+			}
+			return status, body, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		case <-time.After(max(backoff, retryAfter)):
+		}
+		backoff *= 2
+	}
+}
+
+// transient reports whether a status means the server could not answer
+// right now. A plain 500 is not retried, since some oracles answer with it.
+func transient(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// refusal maps a status an oracle server refuses queries with to the error
+// the refusing Middleware would have answered with: 403 to ErrLockedOut, 429
+// to ErrRateLimited and 502, 503 and 504 to ErrUnavailable, in a
+// RetryAfterError if the response said how long to wait. It returns nil for
+// any other status.
+func refusal(status int, retryAfter time.Duration) error {
+	var err error
+	switch status {
+	case http.StatusForbidden:
+		err = ErrLockedOut
+	case http.StatusTooManyRequests:
+		err = ErrRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		err = ErrUnavailable
+	default:
+		return nil
+	}
+	if retryAfter > 0 {
+		return &RetryAfterError{Err: err, After: retryAfter}
+	}
+	return err
+}
+
+// do sends req and returns the response status, body and Retry-After.
+func (o *HTTPOracle) do(req *http.Request) (int, []byte, time.Duration, error) {
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	// Reading the body to the end lets the connection be reused.
+	defer resp.Body.Close()
+
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, retryAfter, err
+}
+
+// Answer sends src and reads the answer: for OpPadding whether the response
+// means the input was accepted, and otherwise the output decoded from the
+// response, which must have a 2xx status. A query that cannot be sent, that
+// is still refused after the retries, or that a 403 says the server has
+// locked out is answered with its error, so that a refusal is never taken
+// for invalid padding.
+func (o *HTTPOracle) Answer(ctx context.Context, op Op, src []byte) Result {
+	status, body, err := o.Query(ctx, src)
+	if err != nil {
+		return Result{Err: err}
+	}
+	if status == http.StatusForbidden {
+		return Result{Err: fmt.Errorf("status %d: %w", status, ErrLockedOut)}
+	}
+
+	if op == OpPadding {
+		valid := o.Response.Valid
+		if valid == nil {
+			valid = func(status int, _ []byte) bool {
+				return status >= 200 && status <= 299
+			}
+		}
+		return Result{Valid: valid(status, body)}
+	}
+
+	if status < 200 || status > 299 {
+		return Result{Err: fmt.Errorf("status %d: %s", status, strings.TrimSpace(string(body)))}
+	}
+	out := strings.TrimSpace(string(body))
+	if o.Response.Field != "" {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return Result{Err: fmt.Errorf("bad response: %w", err)}
+		}
+		if err := json.Unmarshal(fields[o.Response.Field], &out); err != nil {
+			return Result{Err: fmt.Errorf("bad response field %q: %w", o.Response.Field, err)}
+		}
+	}
+	dst, err := decodeAs(o.Request.Encoding, out)
+	if err != nil {
+		return Result{Err: fmt.Errorf("bad response %q: %w", out, err)}
+	}
+	return Result{Output: dst}
+}
+
+func (o *HTTPOracle) Encrypt(src []byte) []byte {
+	return o.Answer(context.Background(), OpEncrypt, src).Output
+}
+
+func (o *HTTPOracle) Decrypt(src []byte) []byte {
+	return o.Answer(context.Background(), OpDecrypt, src).Output
+}
+
+func (o *HTTPOracle) ValidPadding(src []byte) bool {
+	return o.Answer(context.Background(), OpPadding, src).Valid
+}
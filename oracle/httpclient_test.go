@@ -0,0 +1,136 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+func TestHTTPOracleBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Query().Get("data")))
+	}))
+	defer srv.Close()
+
+	o := NewHTTPOracle(Request{URL: srv.URL, In: InQuery, Name: "data", Encoding: codec.Hex}, Response{})
+	if got, err := Encrypt(context.Background(), o, []byte("foo")); err != nil || string(got) != "foo" {
+		t.Errorf("got %q, %v, want foo", got, err)
+	}
+}
+
+func TestHTTPOracleRefused(t *testing.T) {
+	tests := []struct {
+		status     int
+		retryAfter string
+		want       error
+		wantAfter  time.Duration
+	}{
+		{http.StatusForbidden, "", ErrLockedOut, 0},
+		{http.StatusTooManyRequests, "2", ErrRateLimited, 2 * time.Second},
+		{http.StatusTooManyRequests, "", ErrRateLimited, 0},
+		{http.StatusServiceUnavailable, "", ErrUnavailable, 0},
+		{http.StatusGatewayTimeout, "", ErrUnavailable, 0},
+	}
+	for _, tt := range tests {
+		var requests int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if tt.retryAfter != "" {
+				w.Header().Set("Retry-After", tt.retryAfter)
+			}
+			w.WriteHeader(tt.status)
+		}))
+
+		o := NewHTTPOracle(Request{URL: srv.URL, In: InQuery, Name: "data", Encoding: codec.Hex}, Response{})
+		// Retry-After is only waited out between attempts, so with no
+		// retries the test does not sleep.
+		o.Retries, o.Backoff = 0, time.Millisecond
+		valid, err := ValidPadding(context.Background(), o, []byte("foo"))
+		srv.Close()
+
+		if valid || !errors.Is(err, tt.want) {
+			t.Errorf("status %d: got %v, %v; want the error %v", tt.status, valid, err, tt.want)
+		}
+		var ra *RetryAfterError
+		if errors.As(err, &ra) != (tt.wantAfter > 0) || (ra != nil && ra.After != tt.wantAfter) {
+			t.Errorf("status %d: error %v, want a retry after of %v", tt.status, err, tt.wantAfter)
+		}
+		if requests != 1 {
+			t.Errorf("status %d: %d requests, want 1", tt.status, requests)
+		}
+	}
+}
+
+func TestHTTPOracleRetriesThenRefuses(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	o := NewHTTPOracle(Request{URL: srv.URL, In: InQuery, Name: "data", Encoding: codec.Hex}, Response{})
+	o.Retries, o.Backoff = 2, time.Millisecond
+	if _, err := ValidPadding(context.Background(), o, []byte("foo")); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("error = %v, want %v", err, ErrRateLimited)
+	}
+	if requests != 3 {
+		t.Errorf("%d requests, want 3", requests)
+	}
+}
+
+func TestHTTPOracleInvalidPadding(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusInternalServerError} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		o := NewHTTPOracle(Request{URL: srv.URL, In: InQuery, Name: "data", Encoding: codec.Hex}, Response{})
+		valid, err := ValidPadding(context.Background(), o, []byte("foo"))
+		srv.Close()
+		if valid || err != nil {
+			t.Errorf("status %d: got %v, %v; want invalid padding", status, valid, err)
+		}
+	}
+}
+
+func TestHTTPOracleBackoffCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	o := NewHTTPOracle(Request{URL: srv.URL, In: InQuery, Name: "data", Encoding: codec.Hex}, Response{})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := o.Query(ctx, []byte("foo"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Query error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Query took %v, still waiting out Retry-After", d)
+	}
+}
+
+func TestHTTPOracleRequestCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	o := NewHTTPOracle(Request{URL: srv.URL, In: InQuery, Name: "data", Encoding: codec.Hex}, Response{})
+	o.Retries = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := o.Query(ctx, []byte("foo")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Query error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,224 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fharding1/cryptopals/random"
+)
+
+// The middleware here makes an oracle behave like a real target that
+// throttles, stalls, fails and locks out its users. Refused queries carry
+// one of these errors in Result.Err; the HTTP handler answers them with a
+// matching status.
+var (
+	ErrRateLimited = errors.New("oracle: rate limited")
+	ErrUnavailable = errors.New("oracle: unavailable")
+	ErrLockedOut   = errors.New("oracle: locked out")
+	ErrOverBudget  = errors.New("oracle: query budget exhausted")
+)
+
+// RetryAfterError is a refusal that says how long to wait before asking
+// again, as RateLimit's are. The HTTP handler sends After as Retry-After.
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", e.Err, e.After)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// Transient reports whether err is a refusal worth retrying.
+func Transient(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
+
+// sleep waits for d, or until ctx is done, returning its error.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// RateLimit is a token bucket holding up to Burst tokens and refilled at
+// Rate a second. Each query takes a token; one that finds none is refused
+// with a RetryAfterError wrapping ErrRateLimited. Its Limit method is a
+// Middleware. It is safe for concurrent use.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+	// Wait makes queries wait for a token rather than be refused.
+	Wait bool
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token, returning zero, or how long until one is available.
+func (l *RateLimit) take() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.last.IsZero() {
+		l.tokens = float64(l.Burst)
+	} else {
+		l.tokens = min(float64(l.Burst), l.tokens+now.Sub(l.last).Seconds()*l.Rate)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.Rate * float64(time.Second))
+}
+
+func (l *RateLimit) Limit(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	for {
+		wait := l.take()
+		if wait == 0 {
+			return next()
+		}
+		if !l.Wait {
+			return Result{Err: &RetryAfterError{Err: ErrRateLimited, After: wait}}
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return Result{Err: err}
+		}
+	}
+}
+
+// Latency delays every query by a random time between Min and Max, drawn
+// from Rand, or crypto/rand if it is nil. Its Delay method is a Middleware.
+type Latency struct {
+	Min, Max time.Duration
+	Rand     io.Reader
+}
+
+func (l Latency) Delay(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	d := l.Min
+	if l.Max > l.Min {
+		d += time.Duration(random.Intn(l.Rand, int(l.Max-l.Min)+1))
+	}
+	if err := sleep(ctx, d); err != nil {
+		return Result{Err: err}
+	}
+	return next()
+}
+
+// Flaky refuses each query with probability Rate, drawn from Rand, or
+// crypto/rand if it is nil. Its Fail method is a Middleware.
+type Flaky struct {
+	Rate float64
+	Rand io.Reader
+}
+
+func (f Flaky) Fail(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	const scale = 1 << 30
+	if float64(random.Intn(f.Rand, scale)) < f.Rate*scale {
+		return Result{Err: ErrUnavailable}
+	}
+	return next()
+}
+
+// Lockout refuses every query once After padding checks in a row have come
+// back invalid, as a service locking out a user who keeps sending bad
+// ciphertexts would. A valid padding resets the count. Its Guard method is a
+// Middleware. It is safe for concurrent use.
+type Lockout struct {
+	After int
+
+	mu      sync.Mutex
+	invalid int
+	locked  bool
+}
+
+func (l *Lockout) Guard(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	l.mu.Lock()
+	locked := l.locked
+	l.mu.Unlock()
+	if locked {
+		return Result{Err: ErrLockedOut}
+	}
+
+	res := next()
+	if op != OpPadding || res.Err != nil {
+		return res
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if res.Valid {
+		l.invalid = 0
+	} else if l.invalid++; l.invalid >= l.After {
+		l.locked = true
+	}
+	return res
+}
+
+// Locked reports whether the lockout has been triggered.
+func (l *Lockout) Locked() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.locked
+}
+
+// Retry tries a query refused with a Transient error up to Attempts more
+// times, waiting Backoff and then twice as long each time, or as long as a
+// RetryAfterError asks if that is longer. It stops waiting once the query's
+// context is done. Its Do method is a Middleware, which goes outside the
+// ones that refuse. It is safe for concurrent use.
+type Retry struct {
+	Attempts int
+	Backoff  time.Duration
+
+	mu      sync.Mutex
+	retries int
+}
+
+func (r *Retry) Do(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	backoff := r.Backoff
+	for attempt := 0; ; attempt++ {
+		res := next()
+		if !Transient(res.Err) || attempt >= r.Attempts {
+			return res
+		}
+
+		r.mu.Lock()
+		r.retries++
+		r.mu.Unlock()
+
+		wait := backoff
+		var ra *RetryAfterError
+		if errors.As(res.Err, &ra) {
+			wait = max(wait, ra.After)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return Result{Err: err}
+		}
+		backoff *= 2
+	}
+}
+
+// Retries returns how many times queries have been retried.
+func (r *Retry) Retries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retries
+}
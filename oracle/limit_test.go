@@ -0,0 +1,47 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryWaitsRetryAfter(t *testing.T) {
+	limit := &RateLimit{Rate: 20, Burst: 1}
+	retry := &Retry{Attempts: 1, Backoff: time.Millisecond}
+	o := WrapEncryption(EncryptFunc(func(src []byte) []byte { return src }), retry.Do, limit.Limit)
+
+	for i := range 2 {
+		if _, err := Encrypt(context.Background(), o, []byte("foo")); err != nil {
+			t.Fatalf("query %d: %v", i+1, err)
+		}
+	}
+	if n := retry.Retries(); n != 1 {
+		t.Errorf("%d retries, want 1 waiting out the rate limit", n)
+	}
+}
+
+func TestLatencyCancelled(t *testing.T) {
+	o := WrapPadding(PaddingFunc(func([]byte) bool { return true }), Latency{Min: time.Minute}.Delay)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := ValidPadding(ctx, o, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimitWaitCancelled(t *testing.T) {
+	limit := &RateLimit{Rate: 0.001, Burst: 1, Wait: true}
+	o := WrapEncryption(EncryptFunc(func(src []byte) []byte { return src }), limit.Limit)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := Encrypt(ctx, o, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Encrypt(ctx, o, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
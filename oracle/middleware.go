@@ -0,0 +1,248 @@
+package oracle
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Op is the kind of query a Middleware sees.
+type Op string
+
+const (
+	OpEncrypt Op = "encrypt"
+	OpDecrypt Op = "decrypt"
+	OpPadding Op = "padding"
+)
+
+// Result is the answer to one query: Output for encryption and decryption,
+// Valid for padding checks. Err is set when the query was refused or the
+// oracle failed to answer it.
+type Result struct {
+	Output []byte
+	Valid  bool
+	Err    error
+}
+
+// Middleware wraps one query. It calls next to pass the query on, or returns
+// a Result with only Err set without calling it to refuse the query. It may
+// call next more than once to retry, and should give up waiting once ctx is
+// done.
+type Middleware func(ctx context.Context, op Op, src []byte, next func() Result) Result
+
+func chain(ctx context.Context, op Op, src []byte, mws []Middleware, last func() Result) Result {
+	if len(mws) == 0 {
+		return last()
+	}
+	return mws[0](ctx, op, src, func() Result {
+		return chain(ctx, op, src, mws[1:], last)
+	})
+}
+
+// Answerer is implemented by oracles, such as Replay, HTTPOracle and the
+// ones the Wrap functions return, that can answer with a whole Result, so
+// that a refusal or failure reaches whoever asked rather than coming back as
+// nil or false.
+type Answerer interface {
+	Answer(ctx context.Context, op Op, src []byte) Result
+}
+
+// ask puts a query to o, through its Answer method if it has one and
+// otherwise by calling plain.
+func ask(ctx context.Context, o any, op Op, src []byte, plain func() Result) Result {
+	if a, ok := o.(Answerer); ok {
+		return a.Answer(ctx, op, src)
+	}
+	return plain()
+}
+
+// Encrypt asks o to encrypt src. Unlike o.Encrypt, it returns the error o or
+// the middleware wrapping it refused the query with.
+func Encrypt(ctx context.Context, o EncryptionOracle, src []byte) ([]byte, error) {
+	res := ask(ctx, o, OpEncrypt, src, func() Result { return Result{Output: o.Encrypt(src)} })
+	return res.Output, res.Err
+}
+
+// Decrypt asks o to decrypt src. A rejected ciphertext is nil with no error;
+// the error is for a refused query.
+func Decrypt(ctx context.Context, o DecryptionOracle, src []byte) ([]byte, error) {
+	res := ask(ctx, o, OpDecrypt, src, func() Result { return Result{Output: o.Decrypt(src)} })
+	return res.Output, res.Err
+}
+
+// ValidPadding asks o whether src is validly padded. Unlike o.ValidPadding,
+// it returns the error o or the middleware wrapping it refused the query
+// with, rather than false.
+func ValidPadding(ctx context.Context, o PaddingOracle, src []byte) (bool, error) {
+	res := ask(ctx, o, OpPadding, src, func() Result { return Result{Valid: o.ValidPadding(src)} })
+	return res.Valid, res.Err
+}
+
+// wrapped passes every query to o through mws. Its plain methods answer a
+// refused query with nil or false; use the package's Encrypt, Decrypt and
+// ValidPadding functions to see why.
+type wrapped struct {
+	o   any
+	mws []Middleware
+}
+
+func (w wrapped) Answer(ctx context.Context, op Op, src []byte) Result {
+	return chain(ctx, op, src, w.mws, func() Result {
+		switch op {
+		case OpEncrypt:
+			o := w.o.(EncryptionOracle)
+			return ask(ctx, o, op, src, func() Result { return Result{Output: o.Encrypt(src)} })
+		case OpDecrypt:
+			o := w.o.(DecryptionOracle)
+			return ask(ctx, o, op, src, func() Result { return Result{Output: o.Decrypt(src)} })
+		default:
+			o := w.o.(PaddingOracle)
+			return ask(ctx, o, op, src, func() Result { return Result{Valid: o.ValidPadding(src)} })
+		}
+	})
+}
+
+func (w wrapped) Encrypt(src []byte) []byte {
+	return w.Answer(context.Background(), OpEncrypt, src).Output
+}
+
+func (w wrapped) Decrypt(src []byte) []byte {
+	return w.Answer(context.Background(), OpDecrypt, src).Output
+}
+
+func (w wrapped) ValidPadding(src []byte) bool {
+	return w.Answer(context.Background(), OpPadding, src).Valid
+}
+
+// WrapEncryption passes every query to o through mws, the first outermost.
+func WrapEncryption(o EncryptionOracle, mws ...Middleware) EncryptionOracle {
+	return wrapped{o, mws}
+}
+
+// WrapDecryption passes every query to o through mws, the first outermost.
+func WrapDecryption(o DecryptionOracle, mws ...Middleware) DecryptionOracle {
+	return wrapped{o, mws}
+}
+
+// WrapEncDec passes every query to o through mws, the first outermost.
+func WrapEncDec(o EncDecOracle, mws ...Middleware) EncDecOracle {
+	return wrapped{o, mws}
+}
+
+// WrapPadding passes every query to o through mws, the first outermost.
+func WrapPadding(o PaddingOracle, mws ...Middleware) PaddingOracle {
+	return wrapped{o, mws}
+}
+
+// MeterStats summarises the queries a Meter has seen.
+type MeterStats struct {
+	Queries int
+	ByOp    map[Op]int
+	// Refused counts queries middleware further in refused.
+	Refused int
+	// Total is the time spent waiting on the oracle.
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Mean is the average time the oracle took to answer a query.
+func (s MeterStats) Mean() time.Duration {
+	if s.Queries == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Queries)
+}
+
+// Meter counts queries and times how long the oracle takes to answer them.
+// Its Measure method is a Middleware. It is safe for concurrent use.
+type Meter struct {
+	mu    sync.Mutex
+	stats MeterStats
+}
+
+func (m *Meter) Measure(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	start := time.Now()
+	res := next()
+	d := time.Since(start)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stats.ByOp == nil {
+		m.stats.ByOp = make(map[Op]int)
+	}
+	m.stats.Queries++
+	m.stats.ByOp[op]++
+	if res.Err != nil {
+		m.stats.Refused++
+	}
+	m.stats.Total += d
+	m.stats.Max = max(m.stats.Max, d)
+	return res
+}
+
+func (m *Meter) Stats() MeterStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.ByOp = make(map[Op]int, len(m.stats.ByOp))
+	for op, n := range m.stats.ByOp {
+		stats.ByOp[op] = n
+	}
+	return stats
+}
+
+// Budget refuses queries once Limit have been let through with
+// ErrOverBudget. Its Enforce method is a Middleware. It is for limiting what
+// others may ask of an oracle; attacks limit themselves with
+// attack.Options.Budget, which stops them cleanly with what they have
+// recovered.
+type Budget struct {
+	Limit int
+
+	mu      sync.Mutex
+	used    int
+	refused int
+}
+
+func (b *Budget) Enforce(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	b.mu.Lock()
+	if b.used >= b.Limit {
+		b.refused++
+		b.mu.Unlock()
+		return Result{Err: ErrOverBudget}
+	}
+	b.used++
+	b.mu.Unlock()
+
+	return next()
+}
+
+// Exhausted reports whether any query has been refused.
+func (b *Budget) Exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.refused > 0
+}
+
+// Log returns a Middleware that logs every query to l at debug level, with
+// the sizes of its input and output and how long it took.
+func Log(l *slog.Logger) Middleware {
+	return func(ctx context.Context, op Op, src []byte, next func() Result) Result {
+		start := time.Now()
+		res := next()
+
+		attrs := []any{slog.String("op", string(op)), slog.Int("in", len(src)), slog.Duration("took", time.Since(start))}
+		switch {
+		case res.Err != nil:
+			attrs = append(attrs, slog.String("refused", res.Err.Error()))
+		case op == OpPadding:
+			attrs = append(attrs, slog.Bool("valid", res.Valid))
+		default:
+			attrs = append(attrs, slog.Int("out", len(res.Output)))
+		}
+		l.Debug("oracle query", attrs...)
+		return res
+	}
+}
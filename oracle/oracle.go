@@ -0,0 +1,70 @@
+// Package oracle holds the encryption oracles the challenges attack. Every
+// oracle draws its keys, IVs and other secrets from the io.Reader it is built
+// with: crypto/rand if that is nil, or random.Seeded to make a run
+// reproducible.
+package oracle
+
+import (
+	"io"
+	"slices"
+
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/random"
+)
+
+// Secrets fixes values an oracle would otherwise choose itself, so that a
+// served oracle can be configured. Nil fields keep the oracle's default.
+type Secrets struct {
+	// Key is the AES key for the oracles with a key fixed for their
+	// lifetime.
+	Key []byte
+	// Suffix is the secret ByteAtATime appends to its input.
+	Suffix []byte
+}
+
+// key returns s.Key, or a new 16 byte key from rand if it is nil.
+func (s Secrets) key(rand io.Reader) []byte {
+	if s.Key != nil {
+		return s.Key
+	}
+	key := make([]byte, 16)
+	random.Read(rand, key)
+	return key
+}
+
+// ModeDetection returns an oracle that surrounds its input with 5 to 10
+// random bytes on each side and encrypts it under a fresh random key with
+// either ECB or CBC, chosen once up front. The boolean reports whether CBC
+// was chosen.
+func ModeDetection(rand io.Reader) (func(src []byte) []byte, bool) {
+	cbc := random.Intn(rand, 2) == 0
+	return func(src []byte) []byte {
+		key := make([]byte, 16)
+		iv := make([]byte, 16)
+		prefix := make([]byte, 5+random.Intn(rand, 5))
+		suffix := make([]byte, 5+random.Intn(rand, 5))
+
+		random.Read(rand, key)
+		random.Read(rand, iv)
+		random.Read(rand, prefix)
+		random.Read(rand, suffix)
+
+		ptxt := modes.PKCS7Pad(slices.Concat(prefix, src, suffix), len(key))
+		ctxt := make([]byte, len(ptxt))
+		if cbc {
+			block, err := modes.NewAESCBC(key, iv)
+			if err != nil {
+				panic(err)
+			}
+			block.HandleBytes(ctxt, ptxt, true)
+		} else {
+			block, err := modes.NewAESECB(key)
+			if err != nil {
+				panic(err)
+			}
+			block.HandleBytes(ctxt, ptxt, true)
+		}
+
+		return ctxt
+	}, cbc
+}
@@ -0,0 +1,78 @@
+package oracle
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strconv"
+
+	"github.com/fharding1/cryptopals/kv"
+)
+
+// EmailCheck is how strictly a ProfilePolicy validates emails.
+type EmailCheck int
+
+const (
+	// LenientEmail accepts anything net/mail parses as one address,
+	// display names, comments and quoted local parts included.
+	LenientEmail EmailCheck = iota
+	// StrictEmail accepts only a bare local@domain made of letters, digits
+	// and ".", "_", "+" and "-".
+	StrictEmail
+)
+
+var strictEmail = regexp.MustCompile(`^[A-Za-z0-9._+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*$`)
+
+func (c EmailCheck) String() string {
+	switch c {
+	case LenientEmail:
+		return "lenient"
+	case StrictEmail:
+		return "strict"
+	default:
+		return "EmailCheck(" + strconv.Itoa(int(c)) + ")"
+	}
+}
+
+func (c EmailCheck) check(email string) error {
+	if c == StrictEmail {
+		if !strictEmail.MatchString(email) {
+			return errors.New("not a plain email address: " + strconv.Quote(email))
+		}
+		return nil
+	}
+	_, err := mail.ParseAddress(email)
+	return err
+}
+
+// ProfilePolicy is how profiles are encoded and decoded: their key=value
+// format, which decides escaping and repeated keys, and how strictly emails
+// are checked.
+type ProfilePolicy struct {
+	Codec kv.Codec
+	Email EmailCheck
+}
+
+// DefaultProfilePolicy is the profile oracle's: kv.Query, where a repeated
+// key overwrites earlier ones, with lenient emails.
+var DefaultProfilePolicy = ProfilePolicy{Codec: kv.Query, Email: LenientEmail}
+
+// Encode returns p encoded, after checking its email.
+func (pp ProfilePolicy) Encode(p Profile) (string, error) {
+	if err := pp.Email.check(p.Email); err != nil {
+		return "", err
+	}
+	return pp.Codec.Marshal(p)
+}
+
+// Decode sets the fields str gives, which must leave p with a valid email or
+// none.
+func (pp ProfilePolicy) Decode(str string, p *Profile) error {
+	if err := pp.Codec.Unmarshal(str, p); err != nil {
+		return err
+	}
+	if p.Email != "" {
+		return pp.Email.check(p.Email)
+	}
+	return nil
+}
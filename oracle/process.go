@@ -0,0 +1,162 @@
+package oracle
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+// Process is an oracle running as a subprocess that speaks the line
+// protocol on its stdin and stdout. Its plain methods answer a failed query
+// with nil or false; the package's Encrypt, Decrypt and ValidPadding
+// functions return the error. It is safe for concurrent use.
+type Process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+
+	mu  sync.Mutex
+	err error
+}
+
+// StartProcess starts name with args. The subprocess's stderr is passed
+// through.
+func StartProcess(name string, args ...string) (*Process, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &Process{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// Query sends one request and returns the raw response line. Once writing
+// or reading fails, every later query fails with the same error.
+func (p *Process) Query(verb string, src []byte) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return "", p.err
+	}
+
+	req := codec.HexEncodeToString(src)
+	if verb != "" {
+		req = verb + " " + req
+	}
+	if _, err := io.WriteString(p.stdin, req+"\n"); err != nil {
+		p.err = fmt.Errorf("writing request: %w", err)
+		return "", p.err
+	}
+
+	line, err := p.stdout.ReadString('\n')
+	if err != nil {
+		p.err = fmt.Errorf("reading response: %w", err)
+		return "", p.err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p *Process) answer(ctx context.Context, op Op, verb string, src []byte) Result {
+	if err := ctx.Err(); err != nil {
+		return Result{Err: err}
+	}
+	resp, err := p.Query(verb, src)
+	if err != nil {
+		return Result{Err: err}
+	}
+
+	switch {
+	case resp == "error":
+		// A rejected input: nil output, or invalid padding.
+		return Result{}
+	case strings.HasPrefix(resp, "error "):
+		return Result{Err: errors.New(resp)}
+	case op == OpPadding && resp == "ok":
+		return Result{Valid: true}
+	case op == OpPadding:
+		return Result{Err: fmt.Errorf("bad response %q", resp)}
+	}
+
+	out, err := codec.HexDecodeString(resp)
+	if err != nil {
+		return Result{Err: fmt.Errorf("bad response %q: %w", resp, err)}
+	}
+	return Result{Output: out}
+}
+
+// Answer sends src with the verb for op, as EncDecHandler and PairHandler
+// expect: "enc" to encrypt, and "dec" to decrypt or to ask about padding. A
+// failure talking to the subprocess, or an "error" response carrying a
+// message, is answered with its error; a bare "error" is a rejected input.
+func (p *Process) Answer(ctx context.Context, op Op, src []byte) Result {
+	verb := "dec"
+	if op == OpEncrypt {
+		verb = "enc"
+	}
+	return p.answer(ctx, op, verb, src)
+}
+
+func (p *Process) Encrypt(src []byte) []byte {
+	return p.Answer(context.Background(), OpEncrypt, src).Output
+}
+
+func (p *Process) Decrypt(src []byte) []byte {
+	return p.Answer(context.Background(), OpDecrypt, src).Output
+}
+
+func (p *Process) ValidPadding(src []byte) bool {
+	return p.Answer(context.Background(), OpPadding, src).Valid
+}
+
+// PlainProcess is a Process sending requests with no verb, as BytesHandler
+// and ValidHandler expect.
+type PlainProcess struct {
+	p *Process
+}
+
+// Plain returns the process as an oracle that sends requests with no verb.
+func (p *Process) Plain() PlainProcess {
+	return PlainProcess{p}
+}
+
+// Answer sends src with no verb and reads the answer as Process.Answer does.
+func (pp PlainProcess) Answer(ctx context.Context, op Op, src []byte) Result {
+	return pp.p.answer(ctx, op, "", src)
+}
+
+func (pp PlainProcess) Encrypt(src []byte) []byte {
+	return pp.Answer(context.Background(), OpEncrypt, src).Output
+}
+
+func (pp PlainProcess) Decrypt(src []byte) []byte {
+	return pp.Answer(context.Background(), OpDecrypt, src).Output
+}
+
+func (pp PlainProcess) ValidPadding(src []byte) bool {
+	return pp.Answer(context.Background(), OpPadding, src).Valid
+}
+
+// Close closes the subprocess's stdin and waits for it to exit.
+func (p *Process) Close() error {
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
@@ -0,0 +1,99 @@
+package oracle
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pipeProcess returns a Process talking the line protocol to handle over
+// pipes rather than to a subprocess.
+func pipeProcess(t *testing.T, handle Handler) *Process {
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	go func() {
+		Serve(reqR, respW, handle)
+		respW.Close()
+	}()
+	t.Cleanup(func() { reqW.Close() })
+	return &Process{stdin: reqW, stdout: bufio.NewReader(respR)}
+}
+
+func TestProcessEncDec(t *testing.T) {
+	p := pipeProcess(t, EncDecHandler(func(src []byte, enc bool) []byte {
+		if !enc && len(src) == 0 {
+			return nil
+		}
+		return bytes.ToUpper(src)
+	}))
+	ctx := context.Background()
+
+	if got, err := Encrypt(ctx, p, []byte("foo")); err != nil || string(got) != "FOO" {
+		t.Errorf("enc foo = %q, %v, want FOO", got, err)
+	}
+	if got, err := Decrypt(ctx, p, nil); err != nil || got != nil {
+		t.Errorf("dec of a rejected input = %q, %v, want nil and no error", got, err)
+	}
+}
+
+func TestProcessPair(t *testing.T) {
+	p := pipeProcess(t, PairHandler(bytes.ToUpper, func(src []byte) bool {
+		return string(src) == "ok"
+	}))
+	ctx := context.Background()
+
+	if got, err := Encrypt(ctx, p, []byte("foo")); err != nil || string(got) != "FOO" {
+		t.Errorf("enc foo = %q, %v, want FOO", got, err)
+	}
+	for _, in := range []string{"ok", "bad"} {
+		if valid, err := ValidPadding(ctx, p, []byte(in)); err != nil || valid != (in == "ok") {
+			t.Errorf("padding of %q = %v, %v", in, valid, err)
+		}
+	}
+}
+
+func TestProcessPlain(t *testing.T) {
+	p := pipeProcess(t, ValidHandler(func(src []byte) bool { return len(src) > 0 }))
+
+	valid, err := ValidPadding(context.Background(), p.Plain(), []byte("foo"))
+	if err != nil || !valid {
+		t.Errorf("padding of foo = %v, %v, want true", valid, err)
+	}
+}
+
+func TestProcessNoOutput(t *testing.T) {
+	p := pipeProcess(t, BytesHandler(func(src []byte) []byte { return nil }))
+
+	got, err := Encrypt(context.Background(), p.Plain(), []byte("foo"))
+	if got != nil || err == nil || !strings.Contains(err.Error(), errNoOutput.Error()) {
+		t.Errorf("got %q, %v, want the error %q", got, err, errNoOutput)
+	}
+}
+
+func TestProcessErrorMessage(t *testing.T) {
+	p := pipeProcess(t, EncDecHandler(func(src []byte, enc bool) []byte { return src }))
+
+	res := p.answer(context.Background(), OpEncrypt, "frob", []byte("foo"))
+	if res.Output != nil || res.Err == nil || !strings.Contains(res.Err.Error(), `unknown verb "frob"`) {
+		t.Errorf("got %q, %v, want the server's message", res.Output, res.Err)
+	}
+
+	// The message is about one request; the next still gets an answer.
+	if got, err := Encrypt(context.Background(), p, []byte("foo")); err != nil || string(got) != "foo" {
+		t.Errorf("enc foo after an error = %q, %v", got, err)
+	}
+}
+
+func TestProcessClosed(t *testing.T) {
+	p := pipeProcess(t, BytesHandler(bytes.ToUpper))
+	p.stdin.Close()
+
+	for range 2 {
+		if _, err := Encrypt(context.Background(), p.Plain(), []byte("foo")); err == nil {
+			t.Error("query to a closed process succeeded")
+		}
+	}
+}
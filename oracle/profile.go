@@ -0,0 +1,101 @@
+package oracle
+
+import (
+	"crypto/aes"
+	"fmt"
+	"io"
+
+	"github.com/fharding1/cryptopals/modes"
+)
+
+type Role int
+
+const (
+	User Role = iota
+	Admin
+)
+
+func (r Role) String() string {
+	switch r {
+	case User:
+		return "user"
+	case Admin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+func (r Role) MarshalText() ([]byte, error) {
+	if r != User && r != Admin {
+		return nil, fmt.Errorf("not a valid role: %d", int(r))
+	}
+	return []byte(r.String()), nil
+}
+
+func (r *Role) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "user":
+		*r = User
+		return nil
+	case "admin":
+		*r = Admin
+		return nil
+	}
+	return fmt.Errorf("not a valid role: %v %s", text, text)
+}
+
+type Profile struct {
+	Email string `kv:"email"`
+	UID   int    `kv:"uid"`
+	Role  Role   `kv:"role"`
+}
+
+// Encode returns p in the profile oracle's format, under
+// DefaultProfilePolicy.
+func (p Profile) Encode() (string, error) {
+	return DefaultProfilePolicy.Encode(p)
+}
+
+// Decode sets the fields str gives under DefaultProfilePolicy.
+func (p *Profile) Decode(str string) error {
+	return DefaultProfilePolicy.Decode(str, p)
+}
+
+// ProfileECB returns the cut-and-paste oracle: it encrypts or decrypts with
+// AES-ECB under a key fixed for the life of the oracle. Callers are expected
+// to only encrypt the output of Profile.Encode, for profiles with the User
+// role. Decryption strips the padding and returns nil if it is invalid.
+func ProfileECB(rand io.Reader) func(src []byte, enc bool) []byte {
+	return ProfileECBWith(rand, Secrets{})
+}
+
+// ProfileECBWith is ProfileECB with its key taken from s if set.
+func ProfileECBWith(rand io.Reader, s Secrets) func(src []byte, enc bool) []byte {
+	key := s.key(rand)
+
+	return func(src []byte, enc bool) []byte {
+		block, err := modes.NewAESECB(key)
+		if err != nil {
+			panic(err)
+		}
+
+		if enc {
+			ptxt := modes.PKCS7Pad(src, aes.BlockSize)
+			ctxt := make([]byte, len(ptxt))
+			block.HandleBytes(ctxt, ptxt, true)
+			return ctxt
+		}
+
+		if len(src)%aes.BlockSize != 0 {
+			return nil
+		}
+		ptxt := make([]byte, len(src))
+		block.HandleBytes(ptxt, src, false)
+		ptxt, err = modes.PKCS7Strip(ptxt, aes.BlockSize)
+		if err != nil {
+			return nil
+		}
+		return ptxt
+	}
+}
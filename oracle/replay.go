@@ -0,0 +1,214 @@
+package oracle
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+// A recording holds one query per line as JSON, with binary values in hex:
+//
+//	{"op":"encrypt","in":"41414141","out":"8f4e..."}
+//	{"op":"padding","in":"9d0a...","valid":false}
+//
+// A nil output, such as a rejected decryption, is recorded as "out":null,
+// and a query middleware refused has the refusal in "refused".
+
+type recordedQuery struct {
+	Op      Op      `json:"op"`
+	In      string  `json:"in"`
+	Out     *string `json:"out,omitempty"`
+	Valid   bool    `json:"valid,omitempty"`
+	Refused string  `json:"refused,omitempty"`
+}
+
+// refusals are the errors a recorded refusal is read back as, so that
+// errors.Is still recognises it.
+var refusals = []error{ErrRateLimited, ErrUnavailable, ErrLockedOut, ErrOverBudget, context.Canceled, context.DeadlineExceeded}
+
+// Recorder writes every query it sees to a recording. Its Record method is a
+// Middleware. It is safe for concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	w   *bufio.Writer
+	enc *json.Encoder
+	err error
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	bw := bufio.NewWriter(w)
+	return &Recorder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (r *Recorder) Record(ctx context.Context, op Op, src []byte, next func() Result) Result {
+	res := next()
+
+	q := recordedQuery{Op: op, In: codec.HexEncodeToString(src)}
+	if res.Err != nil {
+		q.Refused = res.Err.Error()
+	} else if op == OpPadding {
+		q.Valid = res.Valid
+	} else if res.Output != nil {
+		out := codec.HexEncodeToString(res.Output)
+		q.Out = &out
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.enc.Encode(q)
+	}
+	return res
+}
+
+// Flush writes any buffered queries and returns the first error writing the
+// recording, if any.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return r.err
+}
+
+// ErrReplayMismatch answers a query the recording has no answer left for,
+// which means the attack has strayed from the recorded run.
+var ErrReplayMismatch = errors.New("oracle: query not in the recording")
+
+type replayKey struct {
+	op Op
+	in string
+}
+
+// Replay is an oracle that answers from a recording instead of doing any
+// cryptography. Each recorded query is answered once, so a repeated query
+// gets the answers it was given in order, such as fresh random IVs. A query
+// with no answer left is answered with an ErrReplayMismatch, so that a
+// drifted replay stops at once, and the first is kept for Err. Replay is
+// safe for concurrent use.
+type Replay struct {
+	mu      sync.Mutex
+	answers map[replayKey][]Result
+	left    int
+	asked   int
+	err     error
+}
+
+// ReadReplay reads a recording written by a Recorder.
+func ReadReplay(r io.Reader) (*Replay, error) {
+	rp := &Replay{answers: make(map[replayKey][]Result)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		var q recordedQuery
+		if err := json.Unmarshal(scanner.Bytes(), &q); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+
+		var res Result
+		switch q.Op {
+		case OpPadding:
+			res.Valid = q.Valid
+		case OpEncrypt, OpDecrypt:
+			if q.Out != nil {
+				out, err := codec.HexDecodeString(*q.Out)
+				if err != nil {
+					return nil, fmt.Errorf("recording line %d: %w", line, err)
+				}
+				// Keep an empty output distinct from a nil one.
+				res.Output = append([]byte{}, out...)
+			}
+		default:
+			return nil, fmt.Errorf("recording line %d: unknown op %q", line, q.Op)
+		}
+		if q.Refused != "" {
+			res = Result{Err: errors.New(q.Refused)}
+			for _, err := range refusals {
+				if rest, ok := strings.CutPrefix(q.Refused, err.Error()); ok {
+					res.Err = fmt.Errorf("%w%s", err, rest)
+					if rest == "" {
+						res.Err = err
+					}
+					break
+				}
+			}
+		}
+
+		k := replayKey{q.Op, q.In}
+		rp.answers[k] = append(rp.answers[k], res)
+		rp.left++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// LoadReplay reads the recording in the file at path.
+func LoadReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadReplay(f)
+}
+
+// Answer returns the next recorded answer to the query, refusals included.
+func (rp *Replay) Answer(ctx context.Context, op Op, src []byte) Result {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	rp.asked++
+	k := replayKey{op, codec.HexEncodeToString(src)}
+	answers := rp.answers[k]
+	if len(answers) == 0 {
+		err := fmt.Errorf("%w: query %d is %s %s", ErrReplayMismatch, rp.asked, op, k.in)
+		if rp.err == nil {
+			rp.err = err
+		}
+		return Result{Err: err}
+	}
+
+	rp.answers[k] = answers[1:]
+	rp.left--
+	return answers[0]
+}
+
+func (rp *Replay) Encrypt(src []byte) []byte {
+	return rp.Answer(context.Background(), OpEncrypt, src).Output
+}
+
+func (rp *Replay) Decrypt(src []byte) []byte {
+	return rp.Answer(context.Background(), OpDecrypt, src).Output
+}
+
+func (rp *Replay) ValidPadding(src []byte) bool {
+	return rp.Answer(context.Background(), OpPadding, src).Valid
+}
+
+// Err returns the error for the first query not in the recording, if any.
+func (rp *Replay) Err() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.err
+}
+
+// Remaining is how many recorded queries have not been asked, which is zero
+// once an attack has repeated its recorded run exactly.
+func (rp *Replay) Remaining() int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.left
+}
@@ -0,0 +1,77 @@
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	limit := &Lockout{After: 1}
+	o := WrapEncDec(EncDecFunc(func(src []byte, enc bool) []byte {
+		if !enc {
+			return nil
+		}
+		return bytes.ToUpper(src)
+	}), rec.Record)
+	p := WrapPadding(PaddingFunc(func(src []byte) bool { return false }), rec.Record, limit.Guard)
+
+	ctx := context.Background()
+	Encrypt(ctx, o, []byte("foo"))
+	Decrypt(ctx, o, []byte("bar"))
+	ValidPadding(ctx, p, []byte("baz"))
+	ValidPadding(ctx, p, []byte("baz"))
+	if err := rec.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Encrypt(ctx, rp, []byte("foo")); err != nil || string(got) != "FOO" {
+		t.Errorf("encrypt foo = %q, %v, want FOO", got, err)
+	}
+	if got, err := Decrypt(ctx, rp, []byte("bar")); err != nil || got != nil {
+		t.Errorf("decrypt bar = %q, %v, want nil", got, err)
+	}
+	if valid, err := ValidPadding(ctx, rp, []byte("baz")); err != nil || valid {
+		t.Errorf("first padding query = %v, %v, want false", valid, err)
+	}
+	if _, err := ValidPadding(ctx, rp, []byte("baz")); !errors.Is(err, ErrLockedOut) {
+		t.Errorf("second padding query error = %v, want the recorded lockout", err)
+	}
+	if n := rp.Remaining(); n != 0 {
+		t.Errorf("%d queries remaining", n)
+	}
+	if err := rp.Err(); err != nil {
+		t.Errorf("Err() = %v after replaying exactly", err)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	rp, err := ReadReplay(strings.NewReader(`{"op":"encrypt","in":"666f6f","out":"464f4f"}` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := Encrypt(ctx, rp, []byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	// Asked again, the query has no answer left.
+	_, err = Encrypt(ctx, rp, []byte("foo"))
+	if !errors.Is(err, ErrReplayMismatch) || !strings.Contains(err.Error(), "query 2 ") {
+		t.Fatalf("error = %v, want a mismatch at query 2", err)
+	}
+	if _, err := Decrypt(ctx, rp, []byte("foo")); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("error = %v, want a mismatch", err)
+	}
+	if err := rp.Err(); err == nil || !strings.Contains(err.Error(), "query 2 ") {
+		t.Errorf("Err() = %v, want the first mismatch", err)
+	}
+}
@@ -0,0 +1,148 @@
+package oracle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fharding1/cryptopals/codec"
+)
+
+// The line protocol lets oracles and attacks run in separate processes,
+// written in any language. Each request is one line holding the input in
+// hex, optionally preceded by a verb and a space when an oracle does more
+// than one thing:
+//
+//	enc 666f6f626172
+//
+// Each response is one line: the output in hex for oracles that return
+// bytes, or "ok" or "error" for oracles that only accept or reject their
+// input. A malformed request is answered with "error" followed by a space and
+// a message.
+
+// Handler answers one protocol request. For oracles that return bytes it
+// returns the output; for validity oracles it returns nil and a nil error for
+// "ok", or errInvalid for "error".
+type Handler func(verb string, src []byte) ([]byte, error)
+
+var errInvalid = errors.New("invalid")
+
+// Serve reads requests from r and writes responses to w until r is
+// exhausted.
+func Serve(r io.Reader, w io.Writer, handle Handler) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	bw := bufio.NewWriter(w)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		verb, arg, ok := strings.Cut(line, " ")
+		if !ok {
+			verb, arg = "", line
+		}
+
+		var resp string
+		src, err := codec.HexDecodeString(arg)
+		if err == nil {
+			var out []byte
+			out, err = handle(verb, src)
+			switch {
+			case errors.Is(err, errInvalid):
+				resp = "error"
+			case err != nil:
+			case out == nil:
+				resp = "ok"
+			default:
+				resp = codec.HexEncodeToString(out)
+			}
+		}
+		if err != nil && resp == "" {
+			resp = "error " + err.Error()
+		}
+
+		if _, err := fmt.Fprintln(bw, resp); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func unknownVerb(verb string) error {
+	return fmt.Errorf("unknown verb %q", verb)
+}
+
+// BytesHandler serves an oracle that returns bytes, with no verb.
+func BytesHandler(o func(src []byte) []byte) Handler {
+	return func(verb string, src []byte) ([]byte, error) {
+		if verb != "" {
+			return nil, unknownVerb(verb)
+		}
+		return output(o(src))
+	}
+}
+
+// ValidHandler serves an oracle that accepts or rejects its input, with no
+// verb.
+func ValidHandler(o func(src []byte) bool) Handler {
+	return func(verb string, src []byte) ([]byte, error) {
+		if verb != "" {
+			return nil, unknownVerb(verb)
+		}
+		if !o(src) {
+			return nil, errInvalid
+		}
+		return nil, nil
+	}
+}
+
+// EncDecHandler serves an oracle that both encrypts and decrypts, with the
+// verbs "enc" and "dec".
+func EncDecHandler(o func(src []byte, enc bool) []byte) Handler {
+	return func(verb string, src []byte) ([]byte, error) {
+		switch verb {
+		case "enc":
+			return output(o(src, true))
+		case "dec":
+			out := o(src, false)
+			if out == nil {
+				return nil, errInvalid
+			}
+			return out, nil
+		}
+		return nil, unknownVerb(verb)
+	}
+}
+
+// PairHandler serves a separate encryption oracle and validity oracle, such
+// as the padding oracle, with the verbs "enc" and "dec".
+func PairHandler(enc func(src []byte) []byte, dec func(src []byte) bool) Handler {
+	return func(verb string, src []byte) ([]byte, error) {
+		switch verb {
+		case "enc":
+			return output(enc(src))
+		case "dec":
+			if !dec(src) {
+				return nil, errInvalid
+			}
+			return nil, nil
+		}
+		return nil, unknownVerb(verb)
+	}
+}
+
+// errNoOutput answers a request the oracle returned nil for where it should
+// have returned bytes, so that the client sees a failure rather than "ok" or
+// an empty output.
+var errNoOutput = errors.New("oracle returned no output")
+
+func output(b []byte) ([]byte, error) {
+	if b == nil {
+		return nil, errNoOutput
+	}
+	return b, nil
+}
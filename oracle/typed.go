@@ -0,0 +1,59 @@
+package oracle
+
+// EncryptionOracle encrypts attacker chosen input, possibly surrounding it
+// with secrets of its own.
+type EncryptionOracle interface {
+	Encrypt(src []byte) []byte
+}
+
+// DecryptionOracle decrypts attacker chosen ciphertext. It returns nil when
+// it rejects the ciphertext.
+type DecryptionOracle interface {
+	Decrypt(src []byte) []byte
+}
+
+// EncDecOracle both encrypts and decrypts under the same key.
+type EncDecOracle interface {
+	EncryptionOracle
+	DecryptionOracle
+}
+
+// PaddingOracle reveals only whether a ciphertext decrypts to validly
+// padded plaintext.
+type PaddingOracle interface {
+	ValidPadding(src []byte) bool
+}
+
+// EncryptFunc adapts a function such as ByteAtATime's to EncryptionOracle.
+type EncryptFunc func(src []byte) []byte
+
+func (f EncryptFunc) Encrypt(src []byte) []byte {
+	return f(src)
+}
+
+// DecryptFunc adapts a decryption function to DecryptionOracle.
+type DecryptFunc func(src []byte) []byte
+
+func (f DecryptFunc) Decrypt(src []byte) []byte {
+	return f(src)
+}
+
+// EncDecFunc adapts a function such as ProfileECB's, which encrypts or
+// decrypts depending on enc, to EncDecOracle.
+type EncDecFunc func(src []byte, enc bool) []byte
+
+func (f EncDecFunc) Encrypt(src []byte) []byte {
+	return f(src, true)
+}
+
+func (f EncDecFunc) Decrypt(src []byte) []byte {
+	return f(src, false)
+}
+
+// PaddingFunc adapts a validity function such as CBCPadding's to
+// PaddingOracle.
+type PaddingFunc func(src []byte) bool
+
+func (f PaddingFunc) ValidPadding(src []byte) bool {
+	return f(src)
+}
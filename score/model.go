@@ -0,0 +1,224 @@
+package score
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+)
+
+// Model holds unigram and, optionally, bigram byte counts from a corpus and
+// scores text by its log likelihood under them.
+type Model struct {
+	unigrams [256]uint64
+	// bigrams is keyed by the previous byte in the high 8 bits and the next
+	// byte in the low 8 bits. It is nil for unigram only models.
+	bigrams map[uint16]uint64
+
+	logUni [256]float64
+	logBi  []float64
+}
+
+const (
+	modelMagic   = "cpfm"
+	modelVersion = 1
+	// smoothing is added to every count so that bytes missing from the
+	// corpus are unlikely rather than impossible.
+	smoothing = 1.0
+)
+
+func newModel(unigrams [256]uint64, bigrams map[uint16]uint64) *Model {
+	m := &Model{unigrams: unigrams, bigrams: bigrams}
+
+	var total uint64
+	for _, n := range unigrams {
+		total += n
+	}
+	for c, n := range unigrams {
+		m.logUni[c] = math.Log((float64(n) + smoothing) / (float64(total) + 256*smoothing))
+	}
+
+	if bigrams == nil {
+		return m
+	}
+
+	var following [256]uint64
+	for k, n := range bigrams {
+		following[k>>8] += n
+	}
+	m.logBi = make([]float64, 1<<16)
+	for k := range m.logBi {
+		prev := k >> 8
+		m.logBi[k] = math.Log((float64(bigrams[uint16(k)]) + smoothing) / (float64(following[prev]) + 256*smoothing))
+	}
+	return m
+}
+
+// Train counts the bytes and byte pairs read from r.
+func Train(r io.Reader) (*Model, error) {
+	var unigrams [256]uint64
+	bigrams := make(map[uint16]uint64)
+
+	br := bufio.NewReader(r)
+	prev, err := br.ReadByte()
+	if err == io.EOF {
+		return nil, errors.New("score: empty corpus")
+	} else if err != nil {
+		return nil, err
+	}
+	unigrams[prev]++
+
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		unigrams[c]++
+		bigrams[uint16(prev)<<8|uint16(c)]++
+		prev = c
+	}
+
+	// WriteTo cannot tell an empty bigram table from none, so a corpus with
+	// no pairs makes a unigram only model.
+	if len(bigrams) == 0 {
+		bigrams = nil
+	}
+	return newModel(unigrams, bigrams), nil
+}
+
+// TrainFile trains a model on the contents of the file at path.
+func TrainFile(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Train(f)
+}
+
+// Score returns the mean log likelihood per byte of b under the model, using
+// bigrams when the model has them.
+func (m *Model) Score(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	total := m.logUni[b[0]]
+	for i := 1; i < len(b); i++ {
+		if m.logBi != nil {
+			total += m.logBi[uint16(b[i-1])<<8|uint16(b[i])]
+		} else {
+			total += m.logUni[b[i]]
+		}
+	}
+	return total / float64(len(b))
+}
+
+// WriteTo serializes the model's counts: a magic string and version, the 256
+// unigram counts as uvarints, then the number of non-zero bigrams followed by
+// each bigram's two bytes and count.
+func (m *Model) WriteTo(w io.Writer) (int64, error) {
+	buf := []byte(modelMagic)
+	buf = append(buf, modelVersion)
+	for _, n := range m.unigrams {
+		buf = binary.AppendUvarint(buf, n)
+	}
+
+	keys := make([]uint16, 0, len(m.bigrams))
+	for k := range m.bigrams {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = binary.BigEndian.AppendUint16(buf, k)
+		buf = binary.AppendUvarint(buf, m.bigrams[k])
+	}
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// ReadModel reads a model written by WriteTo.
+func ReadModel(r io.Reader) (*Model, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(modelMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("score: reading model header: %w", err)
+	}
+	if string(header[:len(modelMagic)]) != modelMagic {
+		return nil, errors.New("score: not a model file")
+	}
+	if v := header[len(modelMagic)]; v != modelVersion {
+		return nil, fmt.Errorf("score: unsupported model version %d", v)
+	}
+
+	var unigrams [256]uint64
+	for i := range unigrams {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("score: reading unigrams: %w", err)
+		}
+		unigrams[i] = n
+	}
+
+	nbigrams, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("score: reading bigram count: %w", err)
+	}
+	if nbigrams > 1<<16 {
+		return nil, fmt.Errorf("score: %d bigrams is more than there are byte pairs", nbigrams)
+	}
+
+	var bigrams map[uint16]uint64
+	if nbigrams > 0 {
+		bigrams = make(map[uint16]uint64, nbigrams)
+	}
+	for range nbigrams {
+		var k [2]byte
+		if _, err := io.ReadFull(br, k[:]); err != nil {
+			return nil, fmt.Errorf("score: reading bigrams: %w", err)
+		}
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("score: reading bigrams: %w", err)
+		}
+		bigrams[binary.BigEndian.Uint16(k[:])] = n
+	}
+
+	return newModel(unigrams, bigrams), nil
+}
+
+// Save writes the model to the file at path.
+func (m *Model) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a model from the file at path.
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadModel(f)
+}
@@ -0,0 +1,108 @@
+package score
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const corpus = `It was the best of times, it was the worst of times, it was the age of
+wisdom, it was the age of foolishness, it was the epoch of belief, it was the
+epoch of incredulity, it was the season of Light, it was the season of Darkness.`
+
+var samples = []string{
+	"",
+	"it was the season of hope",
+	"Cooking MC's like a pound of bacon",
+	"\x1b\x37\x37\x33\x31\x36\x3f\x78\x15",
+	"\x00\xff\x80",
+}
+
+func TestModelRoundTrip(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		corpus string
+	}{
+		{"corpus", corpus},
+		// A single byte has no pairs, so the model is unigram only.
+		{"one byte", "e"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			m, err := Train(strings.NewReader(c.corpus))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			n, err := m.WriteTo(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("WriteTo returned %d, wrote %d bytes", n, buf.Len())
+			}
+
+			read, err := ReadModel(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "model")
+			if err := m.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range samples {
+				want := m.Score([]byte(s))
+				if got := read.Score([]byte(s)); got != want {
+					t.Errorf("read model scores %q %v, want %v", s, got, want)
+				}
+				if got := loaded.Score([]byte(s)); got != want {
+					t.Errorf("loaded model scores %q %v, want %v", s, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestModelScore(t *testing.T) {
+	m, err := Train(strings.NewReader(corpus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if english, noise := m.Score([]byte(samples[1])), m.Score([]byte(samples[3])); english <= noise {
+		t.Errorf("English scores %v, no better than noise at %v", english, noise)
+	}
+}
+
+func TestReadModelErrors(t *testing.T) {
+	m, err := Train(strings.NewReader(corpus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+	good := buf.Bytes()
+
+	for _, c := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"magic", append([]byte("cpfx"), good[4:]...)},
+		{"version", append([]byte("cpfm\x02"), good[5:]...)},
+		{"truncated", good[:len(good)-1]},
+	} {
+		if _, err := ReadModel(bytes.NewReader(c.data)); err == nil {
+			t.Errorf("%s: ReadModel succeeded", c.name)
+		}
+	}
+
+	if _, err := Train(strings.NewReader("")); err == nil {
+		t.Error("Train on an empty corpus succeeded")
+	}
+}
@@ -0,0 +1,95 @@
+// Package score rates how much a byte string looks like plaintext, using
+// English letter frequencies by default or byte models trained on a corpus.
+package score
+
+import "math"
+
+// English holds the relative frequency of each letter in English text, the
+// same table used by the set 1 Python solutions.
+var English = [26]float64{
+	0.0812, // A
+	0.0149, // B
+	0.0271, // C
+	0.0432, // D
+	0.1202, // E
+	0.0230, // F
+	0.0203, // G
+	0.0592, // H
+	0.0731, // I
+	0.0010, // J
+	0.0069, // K
+	0.0398, // L
+	0.0261, // M
+	0.0695, // N
+	0.0768, // O
+	0.0182, // P
+	0.0011, // Q
+	0.0602, // R
+	0.0628, // S
+	0.0910, // T
+	0.0288, // U
+	0.0111, // V
+	0.0209, // W
+	0.0017, // X
+	0.0211, // Y
+	0.0007, // Z
+}
+
+// Share of English text taken up by each class of character.
+const (
+	letterShare      = 0.80
+	spaceShare       = 0.15
+	punctuationShare = 0.045
+	newlineShare     = 0.005
+	// unprintable is the probability given to any byte that should not appear
+	// in text at all.
+	unprintable = 1e-6
+)
+
+// englishScale converts the probabilities above into the integer counts a
+// Model is built from.
+const englishScale = 1e7
+
+func englishCounts() (counts [256]uint64) {
+	probs := make([]float64, 256)
+	for i := range probs {
+		probs[i] = unprintable
+	}
+
+	var npunct int
+	for c := byte('!'); c <= '~'; c++ {
+		if !isLetter(c) {
+			npunct++
+		}
+	}
+	for c := byte('!'); c <= '~'; c++ {
+		if !isLetter(c) {
+			probs[c] = punctuationShare / float64(npunct)
+		}
+	}
+
+	for i, f := range English {
+		probs['a'+i] = letterShare * f * 0.95
+		probs['A'+i] = letterShare * f * 0.05
+	}
+	probs[' '] = spaceShare
+	probs['\n'] = newlineShare
+
+	for i, p := range probs {
+		counts[i] = uint64(math.Round(p * englishScale))
+	}
+	return counts
+}
+
+// Default is a unigram model of English built from the English table.
+var Default = newModel(englishCounts(), nil)
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// Score rates b under the Default model. Higher is better; unprintable bytes
+// are heavily penalised.
+func Score(b []byte) float64 {
+	return Default.Score(b)
+}
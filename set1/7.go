@@ -1,11 +1,14 @@
+//go:build ignore
+
 package main
 
 import (
 	"crypto/aes"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"os"
+
+	"github.com/fharding1/cryptopals/codec"
 )
 
 var key = []byte("YELLOW SUBMARINE")
@@ -14,12 +17,15 @@ func main() {
 	f, _ := os.Open("7.txt")
 	defer f.Close()
 
-	decoder := base64.NewDecoder(base64.StdEncoding, f)
+	decoder, _, err := codec.Detect(f)
+	if err != nil {
+		panic(err)
+	}
 	cipher, _ := aes.NewCipher(key)
 
 	enc := make([]byte, cipher.BlockSize())
 	dec := make([]byte, cipher.BlockSize())
-	_, err := io.ReadFull(decoder, enc)
+	_, err = io.ReadFull(decoder, enc)
 	for err == nil {
 		cipher.Decrypt(dec, enc)
 		_, err = io.ReadFull(decoder, enc)
@@ -1,3 +1,5 @@
+//go:build ignore
+
 package main
 
 import (
@@ -6,6 +8,8 @@ import (
 	"fmt"
 	"os"
 	"slices"
+
+	"github.com/fharding1/cryptopals/codec"
 )
 
 func main() {
@@ -16,8 +20,7 @@ func main() {
 
 	var k int
 	for scanner.Scan() {
-		line := scanner.Text()
-		b, _ := hex.DecodeString(line)
+		b, _, _ := codec.DetectBytes(scanner.Bytes())
 		var i int
 	CheckLoop:
 		for v := range slices.Chunk(b, 16) {
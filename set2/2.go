@@ -1,112 +1,49 @@
+//go:build ignore
+
 package main
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"os"
-	"slices"
-)
-
-func PKCS7Pad(x []byte, blen int) []byte {
-	missing := (-int(len(x))) % int(blen)
-	if missing < 0 {
-		missing += int(blen)
-	} else if missing == 0 {
-		missing = 16
-	}
-
-	pad := make([]byte, missing)
-	for i := range len(pad) {
-		pad[i] = byte(missing)
-	}
-
-	return slices.Concat(x, pad)
-}
-
-func XORBytes(dst, src []byte) {
-	for i := 0; i < len(dst); i++ {
-		dst[i] = dst[i] ^ src[i]
-	}
-}
-
-type CBC struct {
-	prevBlock []byte
-	block     cipher.Block
-	blen      int
-}
-
-func NewAESCBC(key, iv []byte) (CBC, error) {
-	aes, err := aes.NewCipher(key)
-	if err != nil {
-		return CBC{}, err
-	}
-
-	blen := aes.BlockSize()
-	if len(iv) != blen {
-		return CBC{}, fmt.Errorf("iv length %d does not match block length %d", len(iv), blen)
-	}
-
-	prevBlock := make([]byte, blen)
-	copy(prevBlock, iv)
-	return CBC{
-		prevBlock: prevBlock,
-		block:     aes,
-		blen:      blen,
-	}, nil
-}
 
-func (cbc *CBC) handleBlock(dst []byte, block []byte, enc bool) {
-	src := make([]byte, cbc.blen)
-	copy(src, block)
-
-	res := make([]byte, cbc.blen)
-	if enc {
-		XORBytes(src, cbc.prevBlock)
-		cbc.block.Encrypt(res, src)
-		cbc.prevBlock = res
-	} else {
-		cbc.block.Decrypt(res, src)
-		XORBytes(res, cbc.prevBlock)
-		copy(cbc.prevBlock, src)
-	}
-
-	copy(dst, res)
-}
-
-func (cbc *CBC) handleBytes(dst []byte, src []byte, enc bool) {
-	padded := PKCS7Pad(src, cbc.blen)
-
-	blocks := slices.Chunk(padded, cbc.blen)
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/modes"
+)
 
-	var blockIdx int
-	for block := range blocks {
-		cbc.handleBlock(dst[blockIdx*cbc.blen:(blockIdx+1)*cbc.blen], block, enc)
-		blockIdx++
-	}
-}
+var (
+	key = []byte("YELLOW SUBMARINE")
+	iv  = make([]byte, 16)
+)
 
 func main() {
 	f, _ := os.Open("10.txt")
-	contents, _ := io.ReadAll(f)
-	ctxt := make([]byte, base64.StdEncoding.DecodedLen(len(contents)))
-	n, err := base64.StdEncoding.Decode(ctxt, contents)
+	decoder, _, err := codec.Detect(f)
 	if err != nil {
 		panic(err)
 	}
-	ctxt = ctxt[:n]
-	cbc, err := NewAESCBC([]byte("YELLOW SUBMARINE"), []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	ctxt, err := io.ReadAll(decoder)
+	if err != nil {
+		panic(err)
+	}
+	cbc, err := modes.NewAESCBC(key, iv)
 	if err != nil {
 		panic(err)
 	}
 	dec := make([]byte, len(ctxt))
-	cbc.handleBytes(dec, ctxt, false)
+	cbc.HandleBytes(dec, ctxt, false)
+	dec, err = modes.PKCS7Strip(dec, cbc.BlockSize())
+	if err != nil {
+		panic(err)
+	}
 	fmt.Println(string(dec))
 
-	enc := make([]byte, len(dec))
-	cbc, err = NewAESCBC([]byte("YELLOW SUBMARINE"), []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
-	cbc.handleBytes(enc, dec, true)
-	fmt.Println(base64.StdEncoding.EncodeToString(enc))
+	padded := modes.PKCS7Pad(dec, cbc.BlockSize())
+	enc := make([]byte, len(padded))
+	cbc, err = modes.NewAESCBC(key, iv)
+	if err != nil {
+		panic(err)
+	}
+	cbc.HandleBytes(enc, padded, true)
+	fmt.Println(codec.StdEncoding.EncodeToString(enc))
 }
@@ -1,167 +1,58 @@
+//go:build ignore
+
 package main
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
+	"context"
+	"flag"
 	"fmt"
-	"math/rand"
+	"io"
 	"slices"
-)
-
-func PKCS7Pad(x []byte, blen int) []byte {
-	missing := (-int(len(x))) % int(blen)
-	if missing < 0 {
-		missing += int(blen)
-	} else if missing == 0 {
-		missing = 16
-	}
-
-	pad := make([]byte, missing)
-	for i := range len(pad) {
-		pad[i] = byte(missing)
-	}
 
-	return slices.Concat(x, pad)
-}
-
-func XORBytes(dst, src []byte) {
-	for i := 0; i < len(dst); i++ {
-		dst[i] = dst[i] ^ src[i]
-	}
-}
-
-type CBC struct {
-	prevBlock []byte
-	block     cipher.Block
-	blen      int
-}
-
-func NewAESCBC(key, iv []byte) (CBC, error) {
-	aes, err := aes.NewCipher(key)
-	if err != nil {
-		return CBC{}, err
-	}
-
-	blen := aes.BlockSize()
-	if len(iv) != blen {
-		return CBC{}, fmt.Errorf("iv length %d does not match block length %d", len(iv), blen)
-	}
-
-	prevBlock := make([]byte, blen)
-	copy(prevBlock, iv)
-	return CBC{
-		prevBlock: prevBlock,
-		block:     aes,
-		blen:      blen,
-	}, nil
-}
-
-func (cbc *CBC) handleBlock(dst []byte, block []byte, enc bool) {
-	src := make([]byte, cbc.blen)
-	copy(src, block)
-
-	res := make([]byte, cbc.blen)
-	if enc {
-		XORBytes(src, cbc.prevBlock)
-		cbc.block.Encrypt(res, src)
-		cbc.prevBlock = res
-	} else {
-		cbc.block.Decrypt(res, src)
-		XORBytes(res, cbc.prevBlock)
-		copy(cbc.prevBlock, src)
-	}
-
-	copy(dst, res)
-}
-
-func (cbc *CBC) handleBytes(dst []byte, src []byte, enc bool) {
-	blocks := slices.Chunk(src, cbc.blen)
-
-	var blockIdx int
-	for block := range blocks {
-		cbc.handleBlock(dst[blockIdx*cbc.blen:(blockIdx+1)*cbc.blen], block, enc)
-		blockIdx++
-	}
-}
-
-type ECB struct {
-	block cipher.Block
-	blen  int
-}
-
-func NewAESECB(key []byte) (ECB, error) {
-	aes, err := aes.NewCipher(key)
-	if err != nil {
-		return ECB{}, err
-	}
-
-	return ECB{
-		block: aes,
-		blen:  aes.BlockSize(),
-	}, nil
-}
-
-func (ecb *ECB) handleBlock(dst []byte, block []byte, enc bool) {
-	if enc {
-		ecb.block.Encrypt(dst, block)
-	} else {
-		ecb.block.Decrypt(dst, block)
-	}
-}
-
-func (ecb *ECB) handleBytes(dst []byte, src []byte, enc bool) {
-	blocks := slices.Chunk(src, ecb.blen)
+	"github.com/fharding1/cryptopals/attack"
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
 
-	var blockIdx int
-	for block := range blocks {
-		ecb.handleBlock(dst[blockIdx*ecb.blen:(blockIdx+1)*ecb.blen], block, enc)
-		blockIdx++
-	}
-}
+var seed = flag.Int64("seed", 0, "seed for the oracle's choices; 0 picks one at random")
 
-func encryptionOracle() (func(src []byte) []byte, bool) {
-	cbc := rand.Intn(2) == 0
+func encryptionOracle(rand io.Reader) (func(src []byte) []byte, bool) {
+	cbc := random.Intn(rand, 2) == 0
 	return func(src []byte) []byte {
-		key := make([]byte, 16)
-		iv := make([]byte, 16)
-		prefix := make([]byte, 5+rand.Intn(5))
-		suffix := make([]byte, 5+rand.Intn(5))
+		key := random.Bytes(rand, 16)
+		iv := random.Bytes(rand, 16)
+		prefix := random.Bytes(rand, 5+random.Intn(rand, 5))
+		suffix := random.Bytes(rand, 5+random.Intn(rand, 5))
 
-		rand.Read(key)
-		rand.Read(iv)
-		rand.Read(prefix)
-		rand.Read(suffix)
-
-		ptxt := PKCS7Pad(slices.Concat(prefix, src, suffix), len(key))
+		ptxt := modes.PKCS7Pad(slices.Concat(prefix, src, suffix), len(key))
 		ctxt := make([]byte, len(ptxt))
 		if cbc {
-			cbc = true
-			block, err := NewAESCBC(key, iv)
+			block, err := modes.NewAESCBC(key, iv)
 			if err != nil {
 				panic(err)
 			}
-			block.handleBytes(ctxt, ptxt, true)
+			block.HandleBytes(ctxt, ptxt, true)
 		} else {
-			block, err := NewAESECB(key)
+			block, err := modes.NewAESECB(key)
 			if err != nil {
 				panic(err)
 			}
-			block.handleBytes(ctxt, ptxt, true)
+			block.HandleBytes(ctxt, ptxt, true)
 		}
 
 		return ctxt
 	}, cbc
 }
 
-func decideOracle(oracle func([]byte) []byte) bool {
-	ptxt := make([]byte, 16*20)
-	ctxt := oracle(ptxt)
-	blocks := slices.Collect(slices.Chunk(ctxt, 16))
-	return !slices.Equal(blocks[len(blocks)/2], blocks[len(blocks)/2+1])
-}
-
 func main() {
-	oracle, cbc := encryptionOracle()
+	flag.Parse()
+	if *seed == 0 {
+		*seed = random.Int63(nil)
+	}
+	fmt.Println("seed", *seed)
+
+	o, cbc := encryptionOracle(random.Seeded(*seed))
 	fmt.Println(cbc)
-	fmt.Println(decideOracle(oracle))
+	fmt.Println(attack.DecideOracle(context.Background(), oracle.EncryptFunc(o), attack.Options{}))
 }
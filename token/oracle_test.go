@@ -0,0 +1,99 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/fharding1/cryptopals/attack"
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+)
+
+func TestByteAtATimeFails(t *testing.T) {
+	// The attack never decrypts anything: it gives up because every token
+	// has a fresh IV, so equal plaintext blocks never seal to equal
+	// ciphertext blocks as they do under ECB.
+	secret, err := attack.ByteAtATimeECB(context.Background(), oracle.EncryptFunc(ByteAtATime(random.Seeded(1))), attack.Options{})
+	if err == nil || len(secret) > 0 {
+		t.Errorf("recovered %q, %v; want nothing and an error", secret, err)
+	}
+}
+
+func TestCutAndPasteFails(t *testing.T) {
+	p, err := attack.CutAndPaste(context.Background(), oracle.EncDecFunc(Profile(random.Seeded(1))), attack.Options{})
+	if err == nil {
+		t.Fatalf("forged %+v", p)
+	}
+}
+
+func TestBitflipFails(t *testing.T) {
+	ptxt, err := attack.CBCBitflip(context.Background(), oracle.EncDecFunc(Userdata(random.Seeded(1))), attack.Options{})
+	if err == nil {
+		t.Fatalf("forged %q", ptxt)
+	}
+}
+
+func TestPaddingOracleFails(t *testing.T) {
+	enc, valid := CBCPadding(random.Seeded(1))
+	ptxt, err := codec.StdEncoding.DecodeString(oracle.PaddingOracleStrings[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctxt := enc(ptxt)
+	if len(ctxt) < 32 || len(ctxt)%16 != 0 {
+		t.Fatalf("sealed ciphertext is %d bytes, not whole blocks", len(ctxt))
+	}
+
+	got, err := attack.PaddingOracle(context.Background(), ctxt, oracle.PaddingFunc(valid), attack.Options{})
+	if err == nil {
+		t.Fatalf("recovered %q", got)
+	}
+}
+
+// tamper returns ctxt with a bit of its last block flipped.
+func tamper(ctxt []byte) []byte {
+	ctxt = bytes.Clone(ctxt)
+	ctxt[len(ctxt)-1] ^= 1
+	return ctxt
+}
+
+// TestTwoTokens seals two tokens with each oracle and checks that the first
+// still opens once the second has been handed out, and that neither opens
+// once tampered with.
+func TestTwoTokens(t *testing.T) {
+	encDecs := []struct {
+		name string
+		o    func(src []byte, enc bool) []byte
+	}{
+		{"profile", Profile(random.Seeded(1))},
+		{"userdata", Userdata(random.Seeded(1))},
+	}
+	for _, tt := range encDecs {
+		alice, bob := tt.o([]byte("alice"), true), tt.o([]byte("bob"), true)
+		for name, ctxt := range map[string][]byte{"alice": alice, "bob": bob} {
+			if ptxt := tt.o(ctxt, false); !bytes.Contains(ptxt, []byte(name)) {
+				t.Errorf("%s: %s's token opens to %q", tt.name, name, ptxt)
+			}
+			if ptxt := tt.o(tamper(ctxt), false); ptxt != nil {
+				t.Errorf("%s: %s's tampered token opens to %q", tt.name, name, ptxt)
+			}
+		}
+	}
+
+	enc, valid := CBCPadding(random.Seeded(1))
+	first, second := enc([]byte("first token")), enc([]byte("second token"))
+	for i, ctxt := range [][]byte{first, second} {
+		if !valid(ctxt) {
+			t.Errorf("padding: token %d does not open", i+1)
+		}
+		if valid(tamper(ctxt)) {
+			t.Errorf("padding: tampered token %d opens", i+1)
+		}
+	}
+	// A body is only ever framed with the header and tag sealed for its IV.
+	if spliced := append(bytes.Clone(first[:16]), second[16:]...); valid(spliced) {
+		t.Error("padding: the first token's IV with the second's ciphertext opens")
+	}
+}
@@ -0,0 +1,217 @@
+// Package token seals data into authenticated, expiring tokens. It is the
+// fix for the oracles the challenges attack: ByteAtATime, Profile, Userdata
+// and CBCPadding have the same shape as their counterparts in package oracle,
+// but a token that has been tampered with never decrypts, so none of the
+// attacks get anywhere.
+//
+// A token is
+//
+//	version | key id | expiry | IV | AES-CBC ciphertext | HMAC-SHA256 tag
+//
+// with the version and key id one byte each and the expiry in Unix seconds
+// as 8 bytes big endian, 0 meaning never. The tag covers everything before
+// it and is checked before anything is decrypted.
+package token
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fharding1/cryptopals/modes"
+	"github.com/fharding1/cryptopals/random"
+)
+
+// Version is the format version Seal writes and Open accepts.
+const Version = 1
+
+const (
+	headerLen = 1 + 1 + 8
+	tagLen    = sha256.Size
+)
+
+var (
+	ErrMalformed  = errors.New("token: malformed")
+	ErrVersion    = errors.New("token: unsupported version")
+	ErrUnknownKey = errors.New("token: unknown key")
+	ErrForged     = errors.New("token: authentication failed")
+	ErrExpired    = errors.New("token: expired")
+	// ErrKeyringFull is returned by Rotate when all 256 key ids are in use.
+	ErrKeyringFull = errors.New("token: every key id is in use")
+)
+
+// Key is a pair of keys, one for encryption and one for the MAC, and the id
+// tokens name it by.
+type Key struct {
+	ID  byte
+	Enc []byte
+	MAC []byte
+}
+
+// NewKey returns a fresh key with id drawn from rand.
+func NewKey(id byte, rand io.Reader) Key {
+	return Key{ID: id, Enc: random.Bytes(rand, 16), MAC: random.Bytes(rand, 32)}
+}
+
+// Keyring holds the keys tokens may be opened with and the one new tokens
+// are sealed with. It is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[byte]Key
+	current byte
+}
+
+// NewKeyring returns a keyring holding one fresh key drawn from rand.
+func NewKeyring(rand io.Reader) *Keyring {
+	k := NewKey(1, rand)
+	return &Keyring{keys: map[byte]Key{k.ID: k}, current: k.ID}
+}
+
+// Rotate adds a fresh key drawn from rand and seals new tokens with it.
+// Tokens sealed with older keys still open until those are retired. It
+// returns the new key's id, or ErrKeyringFull if every id is taken.
+func (kr *Keyring) Rotate(rand io.Reader) (byte, error) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	id := kr.current + 1
+	for range 256 {
+		if _, ok := kr.keys[id]; !ok {
+			kr.keys[id] = NewKey(id, rand)
+			kr.current = id
+			return id, nil
+		}
+		id++
+	}
+	return 0, ErrKeyringFull
+}
+
+// Add adds k, so tokens sealed with it elsewhere can be opened, without
+// making it current.
+func (kr *Keyring) Add(k Key) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.keys[k.ID] = k
+}
+
+// Retire removes the key with id, so that tokens sealed with it no longer
+// open. The current key cannot be retired.
+func (kr *Keyring) Retire(id byte) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	if id == kr.current {
+		return errors.New("token: cannot retire the current key")
+	}
+	delete(kr.keys, id)
+	return nil
+}
+
+// Current returns the key new tokens are sealed with.
+func (kr *Keyring) Current() Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.keys[kr.current]
+}
+
+func (kr *Keyring) key(id byte) (Key, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	k, ok := kr.keys[id]
+	return k, ok
+}
+
+// Sealer seals and opens tokens.
+type Sealer struct {
+	Keys *Keyring
+	// TTL is how long a token is valid for; zero means forever.
+	TTL time.Duration
+	// Rand supplies IVs; nil means crypto/rand.
+	Rand io.Reader
+	// Now is the clock expiry is measured by; nil means time.Now.
+	Now func() time.Time
+}
+
+// NewSealer returns a sealer with a fresh keyring, drawing keys and IVs from
+// rand, whose tokens last an hour.
+func NewSealer(rand io.Reader) *Sealer {
+	return &Sealer{Keys: NewKeyring(rand), TTL: time.Hour, Rand: rand}
+}
+
+func (s *Sealer) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Seal returns a token holding ptxt under the current key.
+func (s *Sealer) Seal(ptxt []byte) []byte {
+	key := s.Keys.Current()
+
+	var expiry int64
+	if s.TTL > 0 {
+		expiry = s.now().Add(s.TTL).Unix()
+	}
+
+	padded := modes.PKCS7Pad(ptxt, aes.BlockSize)
+	tok := make([]byte, headerLen+aes.BlockSize+len(padded), headerLen+aes.BlockSize+len(padded)+tagLen)
+	tok[0] = Version
+	tok[1] = key.ID
+	binary.BigEndian.PutUint64(tok[2:headerLen], uint64(expiry))
+
+	iv := tok[headerLen : headerLen+aes.BlockSize]
+	random.Read(s.Rand, iv)
+	block, err := modes.NewAESCBC(key.Enc, iv)
+	if err != nil {
+		panic(err)
+	}
+	block.HandleBytes(tok[headerLen+aes.BlockSize:], padded, true)
+
+	return append(tok, mac(key, tok)...)
+}
+
+// Open checks tok and returns the plaintext it holds.
+func (s *Sealer) Open(tok []byte) ([]byte, error) {
+	if len(tok) < headerLen+2*aes.BlockSize+tagLen || (len(tok)-headerLen-tagLen)%aes.BlockSize != 0 {
+		return nil, ErrMalformed
+	}
+	if tok[0] != Version {
+		return nil, ErrVersion
+	}
+	key, ok := s.Keys.key(tok[1])
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	body, tag := tok[:len(tok)-tagLen], tok[len(tok)-tagLen:]
+	if !hmac.Equal(tag, mac(key, body)) {
+		return nil, ErrForged
+	}
+	if expiry := int64(binary.BigEndian.Uint64(tok[2:headerLen])); expiry != 0 && s.now().Unix() >= expiry {
+		return nil, ErrExpired
+	}
+
+	iv := body[headerLen : headerLen+aes.BlockSize]
+	ctxt := body[headerLen+aes.BlockSize:]
+	block, err := modes.NewAESCBC(key.Enc, iv)
+	if err != nil {
+		panic(err)
+	}
+	ptxt := make([]byte, len(ctxt))
+	block.HandleBytes(ptxt, ctxt, false)
+
+	// The tag is good, so bad padding means a bug rather than an attack.
+	return modes.PKCS7Strip(ptxt, aes.BlockSize)
+}
+
+func mac(key Key, body []byte) []byte {
+	h := hmac.New(sha256.New, key.MAC)
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,112 @@
+package token
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fharding1/cryptopals/random"
+)
+
+func TestSealOpen(t *testing.T) {
+	s := NewSealer(random.Seeded(1))
+	for _, n := range []int{0, 1, 15, 16, 17, 100} {
+		ptxt := bytes.Repeat([]byte("x"), n)
+		got, err := s.Open(s.Seal(ptxt))
+		if err != nil || !bytes.Equal(got, ptxt) {
+			t.Errorf("%d bytes: Open(Seal) = %q, %v", n, got, err)
+		}
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	s := NewSealer(random.Seeded(1))
+	tok := s.Seal([]byte("email=foo@bar.com&uid=10&role=user"))
+
+	flip := func(i int) []byte {
+		b := bytes.Clone(tok)
+		b[i] ^= 1
+		return b
+	}
+	tests := []struct {
+		name string
+		tok  []byte
+		want error
+	}{
+		{"truncated", tok[:len(tok)-1], ErrMalformed},
+		{"short", tok[:headerLen+aes.BlockSize+tagLen], ErrMalformed},
+		{"version", flip(0), ErrVersion},
+		{"key id", flip(1), ErrUnknownKey},
+		{"expiry", flip(headerLen - 1), ErrForged},
+		{"iv", flip(headerLen), ErrForged},
+		{"ciphertext", flip(len(tok) - tagLen - 1), ErrForged},
+		{"tag", flip(len(tok) - 1), ErrForged},
+	}
+	for _, tt := range tests {
+		if _, err := s.Open(tt.tok); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Open error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewSealer(random.Seeded(1))
+	s.Now = func() time.Time { return now }
+
+	tok := s.Seal([]byte("hi"))
+	now = now.Add(time.Hour - time.Second)
+	if _, err := s.Open(tok); err != nil {
+		t.Errorf("before expiry: %v", err)
+	}
+	now = now.Add(time.Second)
+	if _, err := s.Open(tok); !errors.Is(err, ErrExpired) {
+		t.Errorf("at expiry: error = %v, want %v", err, ErrExpired)
+	}
+}
+
+func TestRotateRetire(t *testing.T) {
+	rand := random.Seeded(1)
+	s := NewSealer(rand)
+	old := s.Seal([]byte("old"))
+	oldID := s.Keys.Current().ID
+
+	if _, err := s.Keys.Rotate(rand); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(old); err != nil {
+		t.Errorf("token under the previous key: %v", err)
+	}
+	if err := s.Keys.Retire(s.Keys.Current().ID); err == nil {
+		t.Error("retired the current key")
+	}
+	if err := s.Keys.Retire(oldID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(old); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token under a retired key: error = %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestRotateFull(t *testing.T) {
+	rand := random.Seeded(1)
+	kr := NewKeyring(rand)
+	for range 255 {
+		if _, err := kr.Rotate(rand); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := kr.Rotate(rand); !errors.Is(err, ErrKeyringFull) {
+		t.Fatalf("rotating a full keyring: error = %v, want %v", err, ErrKeyringFull)
+	}
+
+	// Retiring a key frees its id for the next rotation.
+	if err := kr.Retire(1); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := kr.Rotate(rand); err != nil || id != 1 {
+		t.Errorf("Rotate = %d, %v; want the retired id 1", id, err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package webapp is a mock web application built on the profile and
+// userdata oracles, so the cut-and-paste and bitflipping attacks can be run
+// end to end through HTTP and cookies.
+//
+// Registering encrypts a profile_for style profile into the "profile"
+// cookie, and logging in encrypts a userdata string holding the username
+// into the "session" cookie. The admin page lets in anyone whose profile
+// decrypts with role=admin or whose session decrypts containing admin=true.
+//
+//	GET  /                   links to the pages below
+//	GET  /register           registration form
+//	POST /register           email, uid -> profile cookie
+//	GET  /login              login form
+//	POST /login              username, password -> session cookie
+//	GET  /account            what the cookies decrypt to
+//	GET  /api/account        the same as {"profile", "session"}, hex or null
+//	GET  /admin              200 for admins, 403 otherwise
+//	POST /logout             clears both cookies
+//
+// Cookie values are hex. The uid registration takes stands in for the
+// argument the cut-and-paste attack chooses; it defaults to 10, as in
+// profile_for. Any password logs in.
+package webapp
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+const (
+	ProfileCookie = "profile"
+	SessionCookie = "session"
+)
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<p><a href="/">home</a> | <a href="/register">register</a> | <a href="/login">log in</a> | <a href="/account">account</a> | <a href="/admin">admin</a></p>
+<h1>{{.Title}}</h1>
+{{if .Error}}<p><strong>{{.Error}}</strong></p>{{end}}
+{{if eq .Title "Register"}}
+<form method="post" action="/register">
+<label>email <input name="email"></label>
+<label>uid <input name="uid" value="10"></label>
+<button>register</button>
+</form>
+{{else if eq .Title "Log in"}}
+<form method="post" action="/login">
+<label>username <input name="username"></label>
+<label>password <input name="password" type="password"></label>
+<button>log in</button>
+</form>
+{{else if eq .Title "Account"}}
+<p>profile: {{if .Profile}}<code>{{printf "%q" .Profile}}</code>{{else}}none{{end}}</p>
+<p>session: {{if .Session}}<code>{{printf "%q" .Session}}</code>{{else}}none{{end}}</p>
+<form method="post" action="/logout"><button>log out</button></form>
+{{else if eq .Title "Admin"}}
+<p>Welcome, administrator. You got in by your {{.Reason}}.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type pageData struct {
+	Title            string
+	Error            string
+	Profile, Session []byte
+	Reason           string
+}
+
+type apiAccount struct {
+	Profile *string `json:"profile"`
+	Session *string `json:"session"`
+}
+
+// New returns the app, encrypting profiles with profile and sessions with
+// userdata. The oracles are not safe for concurrent use, so requests are
+// served one at a time.
+func New(profile, userdata func(src []byte, enc bool) []byte) http.Handler {
+	a := &app{profile: profile, userdata: userdata}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", a.render("Cryptopals Bank"))
+	mux.HandleFunc("GET /register", a.render("Register"))
+	mux.HandleFunc("POST /register", a.register)
+	mux.HandleFunc("GET /login", a.render("Log in"))
+	mux.HandleFunc("POST /login", a.login)
+	mux.HandleFunc("GET /account", a.account)
+	mux.HandleFunc("GET /api/account", a.apiAccount)
+	mux.HandleFunc("GET /admin", a.admin)
+	mux.HandleFunc("POST /logout", a.logout)
+	return mux
+}
+
+type app struct {
+	mu                sync.Mutex
+	profile, userdata func(src []byte, enc bool) []byte
+}
+
+func (a *app) render(title string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		show(w, http.StatusOK, pageData{Title: title})
+	}
+}
+
+func show(w http.ResponseWriter, status int, data pageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	page.Execute(w, data)
+}
+
+func (a *app) register(w http.ResponseWriter, r *http.Request) {
+	uid := 10
+	if s := r.FormValue("uid"); s != "" {
+		var err error
+		if uid, err = strconv.Atoi(s); err != nil {
+			show(w, http.StatusBadRequest, pageData{Title: "Register", Error: "uid: " + err.Error()})
+			return
+		}
+	}
+
+	encoded, err := oracle.Profile{Email: r.FormValue("email"), UID: uid, Role: oracle.User}.Encode()
+	if err != nil {
+		show(w, http.StatusBadRequest, pageData{Title: "Register", Error: err.Error()})
+		return
+	}
+
+	a.mu.Lock()
+	ctxt := a.profile([]byte(encoded), true)
+	a.mu.Unlock()
+
+	setCookie(w, ProfileCookie, codec.HexEncodeToString(ctxt))
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (a *app) login(w http.ResponseWriter, r *http.Request) {
+	username := r.FormValue("username")
+	if username == "" {
+		show(w, http.StatusBadRequest, pageData{Title: "Log in", Error: "no username"})
+		return
+	}
+
+	a.mu.Lock()
+	ctxt := a.userdata([]byte(username), true)
+	a.mu.Unlock()
+
+	setCookie(w, SessionCookie, codec.HexEncodeToString(ctxt))
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (a *app) logout(w http.ResponseWriter, r *http.Request) {
+	for _, name := range []string{ProfileCookie, SessionCookie} {
+		http.SetCookie(w, &http.Cookie{Name: name, Path: "/", MaxAge: -1})
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func setCookie(w http.ResponseWriter, name, value string) {
+	http.SetCookie(w, &http.Cookie{Name: name, Value: value, Path: "/", HttpOnly: true})
+}
+
+// decrypt returns what the request's cookies decrypt to, nil for a cookie
+// that is missing or does not decrypt.
+func (a *app) decrypt(r *http.Request) (profile, session []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if ctxt := cookie(r, ProfileCookie); ctxt != nil {
+		profile = a.profile(ctxt, false)
+	}
+	if ctxt := cookie(r, SessionCookie); ctxt != nil {
+		session = a.userdata(ctxt, false)
+	}
+	return profile, session
+}
+
+func cookie(r *http.Request, name string) []byte {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return nil
+	}
+	ctxt, err := codec.HexDecodeString(c.Value)
+	if err != nil {
+		return nil
+	}
+	return ctxt
+}
+
+func (a *app) account(w http.ResponseWriter, r *http.Request) {
+	profile, session := a.decrypt(r)
+	show(w, http.StatusOK, pageData{Title: "Account", Profile: profile, Session: session})
+}
+
+func (a *app) apiAccount(w http.ResponseWriter, r *http.Request) {
+	profile, session := a.decrypt(r)
+
+	var resp apiAccount
+	if profile != nil {
+		s := codec.HexEncodeToString(profile)
+		resp.Profile = &s
+	}
+	if session != nil {
+		s := codec.HexEncodeToString(session)
+		resp.Session = &s
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (a *app) admin(w http.ResponseWriter, r *http.Request) {
+	profile, session := a.decrypt(r)
+
+	var p oracle.Profile
+	switch {
+	case profile != nil && p.Decode(string(profile)) == nil && p.Role == oracle.Admin:
+		show(w, http.StatusOK, pageData{Title: "Admin", Reason: "profile, role=" + p.Role.String()})
+	case session != nil && oracle.IsAdmin(session):
+		show(w, http.StatusOK, pageData{Title: "Admin", Reason: "session, admin=true"})
+	default:
+		show(w, http.StatusForbidden, pageData{Title: "Forbidden", Error: "admins only"})
+	}
+}
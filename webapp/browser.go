@@ -0,0 +1,213 @@
+package webapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fharding1/cryptopals/codec"
+	"github.com/fharding1/cryptopals/oracle"
+)
+
+// Browser drives the app the way a user with a browser would, keeping its
+// cookies, and exposes the profile and userdata flows as oracles. Decrypting
+// a ciphertext sets it as the browser's cookie and loads the account page,
+// so after an attack the browser holds the forged cookie and Admin shows
+// whether it gets in.
+type Browser struct {
+	URL    string
+	Client *http.Client
+
+	mu      sync.Mutex
+	cookies map[string]string
+	err     error
+}
+
+// NewBrowser returns a browser for the app at base, e.g.
+// "http://localhost:8081".
+func NewBrowser(base string) *Browser {
+	return &Browser{
+		URL: strings.TrimSuffix(base, "/"),
+		Client: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cookies: make(map[string]string),
+	}
+}
+
+// Err returns the first error talking to the app, if any. The oracles
+// cannot return errors, so they return nil once this is set.
+func (b *Browser) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+func (b *Browser) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// do sends a request with the browser's cookies and stores any it is sent
+// back. It returns the response body.
+func (b *Browser) do(method, path string, form url.Values) (int, []byte, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, b.URL+path, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for name, value := range b.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	for _, c := range resp.Cookies() {
+		if c.MaxAge < 0 {
+			delete(b.cookies, c.Name)
+		} else {
+			b.cookies[c.Name] = c.Value
+		}
+	}
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// submit posts form to path and returns the ciphertext in the cookie name
+// it sets.
+func (b *Browser) submit(path string, form url.Values, name string) []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return nil
+	}
+	delete(b.cookies, name)
+	status, _, err := b.do(http.MethodPost, path, form)
+	if err != nil {
+		b.fail(err)
+		return nil
+	}
+	value, ok := b.cookies[name]
+	if !ok {
+		b.fail(fmt.Errorf("POST %s: status %d and no %s cookie", path, status, name))
+		return nil
+	}
+	ctxt, err := codec.HexDecodeString(value)
+	if err != nil {
+		b.fail(fmt.Errorf("%s cookie: %w", name, err))
+		return nil
+	}
+	return ctxt
+}
+
+// load sets ctxt as cookie name and returns what the account API says it
+// decrypts to, nil if it does not.
+func (b *Browser) load(name string, ctxt []byte) []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return nil
+	}
+	b.cookies[name] = codec.HexEncodeToString(ctxt)
+	status, body, err := b.do(http.MethodGet, "/api/account", nil)
+	if err != nil {
+		b.fail(err)
+		return nil
+	}
+	if status != http.StatusOK {
+		b.fail(fmt.Errorf("GET /api/account: status %d", status))
+		return nil
+	}
+
+	var resp apiAccount
+	if err := json.Unmarshal(body, &resp); err != nil {
+		b.fail(fmt.Errorf("GET /api/account: %w", err))
+		return nil
+	}
+	field := resp.Profile
+	if name == SessionCookie {
+		field = resp.Session
+	}
+	if field == nil {
+		return nil
+	}
+	ptxt, err := codec.HexDecodeString(*field)
+	if err != nil {
+		b.fail(fmt.Errorf("GET /api/account: %w", err))
+		return nil
+	}
+	return ptxt
+}
+
+// Profile returns the registration flow as an oracle. Encryption takes an
+// encoded profile and registers its email and uid; the app picks the role.
+func (b *Browser) Profile() oracle.EncDecOracle {
+	return oracle.EncDecFunc(func(src []byte, enc bool) []byte {
+		if !enc {
+			return b.load(ProfileCookie, src)
+		}
+
+		var p oracle.Profile
+		if err := p.Decode(string(src)); err != nil {
+			b.mu.Lock()
+			b.fail(fmt.Errorf("registering %q: %w", src, err))
+			b.mu.Unlock()
+			return nil
+		}
+		return b.submit("/register", url.Values{"email": {p.Email}, "uid": {strconv.Itoa(p.UID)}}, ProfileCookie)
+	})
+}
+
+// Userdata returns the login flow as an oracle. Encryption logs in with src
+// as the username.
+func (b *Browser) Userdata() oracle.EncDecOracle {
+	return oracle.EncDecFunc(func(src []byte, enc bool) []byte {
+		if !enc {
+			return b.load(SessionCookie, src)
+		}
+		return b.submit("/login", url.Values{"username": {string(src)}, "password": {"hunter2"}}, SessionCookie)
+	})
+}
+
+// Admin loads the admin page with the browser's cookies and reports whether
+// it was let in.
+func (b *Browser) Admin() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status, _, err := b.do(http.MethodGet, "/admin", nil)
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusForbidden:
+		return false, nil
+	}
+	return false, fmt.Errorf("GET /admin: status %d", status)
+}
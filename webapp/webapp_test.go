@@ -0,0 +1,134 @@
+package webapp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fharding1/cryptopals/attack"
+	"github.com/fharding1/cryptopals/oracle"
+	"github.com/fharding1/cryptopals/random"
+	"github.com/fharding1/cryptopals/token"
+)
+
+// apps are the app on the challenge oracles and on their token
+// counterparts.
+var apps = []struct {
+	name              string
+	hardened          bool
+	profile, userdata func(rand io.Reader) func(src []byte, enc bool) []byte
+}{
+	{"vulnerable", false, oracle.ProfileECB, oracle.Userdata},
+	{"hardened", true, token.Profile, token.Userdata},
+}
+
+func TestAttacks(t *testing.T) {
+	attacks := []struct {
+		name string
+		run  func(b *Browser) error
+	}{
+		{"cut-and-paste", func(b *Browser) error {
+			_, err := attack.CutAndPaste(context.Background(), b.Profile(), attack.Options{})
+			return err
+		}},
+		{"bitflip", func(b *Browser) error {
+			_, err := attack.CBCBitflip(context.Background(), b.Userdata(), attack.Options{})
+			return err
+		}},
+	}
+
+	for _, app := range apps {
+		for _, a := range attacks {
+			t.Run(app.name+"/"+a.name, func(t *testing.T) {
+				rand := random.Seeded(1)
+				srv := httptest.NewServer(New(app.profile(rand), app.userdata(rand)))
+				defer srv.Close()
+				b := NewBrowser(srv.URL)
+
+				err := a.run(b)
+				if berr := b.Err(); berr != nil {
+					t.Fatal(berr)
+				}
+				admin, aerr := b.Admin()
+				if aerr != nil {
+					t.Fatal(aerr)
+				}
+
+				if app.hardened {
+					if err == nil || admin {
+						t.Errorf("attack error %v, admin %v; want the attack to fail and no way in", err, admin)
+					}
+				} else if err != nil || !admin {
+					t.Errorf("attack error %v, admin %v; want the forged cookie to get in", err, admin)
+				}
+			})
+		}
+	}
+}
+
+func TestTamperedCookies(t *testing.T) {
+	for _, app := range apps {
+		if !app.hardened {
+			continue
+		}
+		rand := random.Seeded(1)
+		srv := httptest.NewServer(New(app.profile(rand), app.userdata(rand)))
+		defer srv.Close()
+		b := NewBrowser(srv.URL)
+
+		flows := []struct {
+			name string
+			o    oracle.EncDecOracle
+			src  string
+		}{
+			{"profile", b.Profile(), "email=foo@bar.com&uid=10&role=user"},
+			{"session", b.Userdata(), "alice"},
+		}
+		for _, f := range flows {
+			ctxt := f.o.Encrypt([]byte(f.src))
+			if ptxt := f.o.Decrypt(ctxt); ptxt == nil {
+				t.Fatalf("%s: the app's own cookie does not decrypt", f.name)
+			}
+			for i := range ctxt {
+				tampered := bytes.Clone(ctxt)
+				tampered[i] ^= 0x80
+				if ptxt := f.o.Decrypt(tampered); ptxt != nil {
+					t.Fatalf("%s: cookie with byte %d flipped decrypts to %q", f.name, i, ptxt)
+				}
+			}
+		}
+		if err := b.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestSessions logs two users in and checks that the first is still logged
+// in after the second.
+func TestSessions(t *testing.T) {
+	for _, app := range apps {
+		t.Run(app.name, func(t *testing.T) {
+			rand := random.Seeded(1)
+			srv := httptest.NewServer(New(app.profile(rand), app.userdata(rand)))
+			defer srv.Close()
+
+			alice, bob := NewBrowser(srv.URL), NewBrowser(srv.URL)
+			aliceSession := alice.Userdata().Encrypt([]byte("alice"))
+			bobSession := bob.Userdata().Encrypt([]byte("bob"))
+
+			if ptxt := alice.Userdata().Decrypt(aliceSession); !bytes.Contains(ptxt, []byte("userdata=alice;")) {
+				t.Errorf("alice's session after bob logged in decrypts to %q", ptxt)
+			}
+			if ptxt := bob.Userdata().Decrypt(bobSession); !bytes.Contains(ptxt, []byte("userdata=bob;")) {
+				t.Errorf("bob's session decrypts to %q", ptxt)
+			}
+			for _, b := range []*Browser{alice, bob} {
+				if err := b.Err(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}